@@ -3,19 +3,24 @@ package ini4go
 import (
 	"bufio"
 	"bytes"
-	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
 	kDefaultSection = "default"
+	kBOM            = "\xef\xbb\xbf"
 )
 
 var sectionRegexp = regexp.MustCompile(`^\[(?P<header>[^]]+)\]$`)
@@ -54,11 +59,27 @@ func New(block bool) *Ini {
 }
 
 type iniParser struct {
-	mutex        sync.RWMutex
-	sectionKeys  []string
-	sections     sync.Map
-	block        bool
-	uniqueOption bool
+	// 配置的版本, 每次修改之后增加, 用于判断 Snapshot 是否过期; 需要通过 atomic 访问, 放在第一个以保证 64 位对齐
+	version              uint64
+	mutex                sync.RWMutex
+	writes               sync.RWMutex // 通过 Section、Option 修改时持有读锁, 见 Snapshot
+	sectionKeys          []string
+	sections             sync.Map
+	block                bool
+	uniqueOption         bool
+	preserveFormat       bool
+	multiline            bool
+	quoteValues          bool
+	inlineCommentMarkers []string
+	interpolation        Interpolation
+	sources              []string
+	loadCount            int
+	listeners            []func(changes *Changes)
+	layout               []*sectionLayout // 开启 preserveFormat 时源文件中每个 section 行开始的一段, 按照源文件中的顺序排列
+	eol                  string           // 开启 preserveFormat 时源文件使用的换行符, 新写入的行使用相同的换行符
+	// 检查错误时使用的临时配置对应的当前配置, 用于检查重复的 option
+	base     *iniParser
+	snapshot atomic.Value
 }
 
 func (this *iniParser) Lock() {
@@ -89,24 +110,81 @@ func (this *iniParser) SetUniqueOption(unique bool) {
 	this.uniqueOption = unique
 }
 
+// SetPreserveFormat 开启后, 加载时会记录原始文本, 写入时未修改的行(包括空行、注释和缩进)保持原样输出,
+// 需要在加载文件之前设置
+func (this *iniParser) SetPreserveFormat(preserve bool) {
+	this.preserveFormat = preserve
+}
+
+// SetMultiline 开启多行值的支持: 以反斜杠结尾的行与下一行合并为一行,
+// 比 option 缩进更多的行作为上一个值的新行(同 Python configparser), 需要在加载文件之前设置;
+// 没有开启 SetQuoteValues 时, 写入以反斜杠结尾或者包含以注释符号开头的行的值会返回错误
+func (this *iniParser) SetMultiline(multiline bool) {
+	this.multiline = multiline
+}
+
+// SetQuoteValues 开启后, 以双引号或者单引号包围的值会去掉引号并处理 \n、\t、\"、\\、\uXXXX 等转义字符,
+// 写入时包含首尾空白、注释符号、换行等字符的值会自动加上引号
+func (this *iniParser) SetQuoteValues(quote bool) {
+	this.quoteValues = quote
+}
+
+// SetInlineCommentMarkers 设置行内注释的符号, 如 "#"、";", 值中前面有空白字符的注释符号及其之后的内容作为行内注释,
+// 默认不支持行内注释, 需要在加载文件之前设置; 没有开启 SetQuoteValues 时, 写入包含行内注释符号的值会返回错误
+func (this *iniParser) SetInlineCommentMarkers(markers ...string) {
+	this.inlineCommentMarkers = markers
+}
+
+// modified 在配置修改之后调用, 使已有的 Snapshot 过期
+func (this *iniParser) modified() {
+	atomic.AddUint64(&this.version, 1)
+}
+
 func (this *iniParser) init() {
+	defer this.modified()
+
+	this.loadCount = 0
 	this.sectionKeys = nil
-	this.sections = sync.Map{}
+	this.layout = nil
+	this.eol = ""
+	// 不替换 sync.Map 本身, 变量替换时会在不持有锁的情况下读取 sections
+	this.sections.Range(func(key, value interface{}) bool {
+		this.sections.Delete(key)
+		return true
+	})
 }
 
 func (this *iniParser) Load(dir string) error {
-	var fileInfo, err = os.Stat(dir)
+	var pathList, err = listFiles(dir)
 	if err != nil {
 		return err
 	}
 
+	this.Lock()
+	defer this.Unlock()
+
+	if err = this.loadFiles(pathList...); err != nil {
+		return err
+	}
+	// 加载成功之后才记录, 避免之后的 Reload 一直失败
+	this.addSource(dir)
+	return nil
+}
+
+// listFiles 返回 dir 下的文件, dir 为文件时返回其本身
+func listFiles(dir string) ([]string, error) {
+	var fileInfo, err = os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+
 	var pathList []string
 
 	if fileInfo.IsDir() {
 		var file *os.File
 		file, err = os.Open(dir)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		var names []string
@@ -114,8 +192,9 @@ func (this *iniParser) Load(dir string) error {
 
 		file.Close()
 		if err != nil {
-			return err
+			return nil, err
 		}
+		sort.Strings(names)
 
 		for _, name := range names {
 			var filePath = path.Join(dir, name)
@@ -131,153 +210,510 @@ func (this *iniParser) Load(dir string) error {
 	} else {
 		pathList = append(pathList, dir)
 	}
-
-	return this.LoadFiles(pathList...)
+	return pathList, nil
 }
 
 func (this *iniParser) LoadFiles(files ...string) error {
 	this.Lock()
 	defer this.Unlock()
 
+	if err := this.loadFiles(files...); err != nil {
+		return err
+	}
+	this.addSource(files...)
+	return nil
+}
+
+func isConfigFile(file string) bool {
+	var ext = filepath.Ext(file)
+	return ext == ".ini" || ext == ".conf"
+}
+
+func (this *iniParser) loadFiles(files ...string) error {
+	var sources []configSource
 	for _, file := range files {
-		var ext = filepath.Ext(file)
-		if ext != ".ini" && ext != ".conf" {
+		if !isConfigFile(file) {
 			continue
 		}
 
-		var f, err = os.OpenFile(file, os.O_RDONLY, 0)
+		var data, err = os.ReadFile(file)
 		if err != nil {
 			return err
 		}
-		err = this.load(f)
-		f.Close()
+		sources = append(sources, configSource{name: file, data: data})
+	}
+	return this.load(sources...)
+}
+
+func (this *iniParser) LoadReader(name string, r io.Reader) error {
+	var data, err = io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	this.Lock()
+	defer this.Unlock()
+
+	return this.load(configSource{name: name, data: data})
+}
+
+func (this *iniParser) LoadBytes(name string, data []byte) error {
+	return this.LoadReader(name, bytes.NewReader(data))
+}
+
+func (this *iniParser) LoadString(name, src string) error {
+	return this.LoadReader(name, strings.NewReader(src))
+}
+
+// LoadFS 加载 fsys 中匹配 patterns 的文件, patterns 使用 fs.Glob 的语法, 为空时加载根目录下的 .ini 和 .conf 文件
+func (this *iniParser) LoadFS(fsys fs.FS, patterns ...string) error {
+	if len(patterns) == 0 {
+		patterns = []string{"*.ini", "*.conf"}
+	}
+
+	var pathList []string
+	var loaded = make(map[string]bool)
+	for _, pattern := range patterns {
+		var matches, err = fs.Glob(fsys, pattern)
 		if err != nil {
 			return err
 		}
+		for _, match := range matches {
+			if loaded[match] {
+				continue
+			}
+			loaded[match] = true
+			pathList = append(pathList, match)
+		}
+	}
+
+	var sources []configSource
+	for _, name := range pathList {
+		var fileInfo, err = fs.Stat(fsys, name)
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() {
+			continue
+		}
+
+		var data []byte
+		if data, err = fs.ReadFile(fsys, name); err != nil {
+			return err
+		}
+		sources = append(sources, configSource{name: name, data: data})
+	}
+
+	this.Lock()
+	defer this.Unlock()
+
+	return this.load(sources...)
+}
+
+// configSource 是一个等待加载的文件或者其它来源的内容
+type configSource struct {
+	name string
+	data []byte
+}
+
+// load 加载 sources, 先全部加载到一个新的配置中检查错误, 没有错误时再加载到当前配置, 出错时当前配置保持不变
+func (this *iniParser) load(sources ...configSource) error {
+	var scratch = &iniParser{}
+	scratch.init()
+	this.copySettings(scratch)
+	scratch.preserveFormat = false
+	scratch.base = this
+
+	for _, source := range sources {
+		if err := scratch.parse(source.name, bytes.NewReader(source.data)); err != nil {
+			return err
+		}
+	}
+	for _, source := range sources {
+		if err := this.parse(source.name, bytes.NewReader(source.data)); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (this *iniParser) load(r io.Reader) error {
+func (this *iniParser) parse(name string, r io.Reader) error {
+	this.loadCount++
+	var order = this.loadCount
+
 	var reader = bufio.NewReader(r)
-	var line []byte
+	var line, eol string
 	var err error
 
 	var currentSection *Section
+	var currentLayout *sectionLayout
+
+	// 最后一个 option 的值, 用于处理缩进续行
+	var lastOption *Option
+	var lastIndex int
+	var lastLayout *lineLayout
+	var lastIndent int
 
 	var index = 0
 	var comments []string
+	var rawLines []string
+	var blanks []string
 	for {
-		if line, _, err = reader.ReadLine(); err != nil {
+		if line, eol, err = readLine(reader); err != nil {
 			if err == io.EOF {
 				break
 			}
 			return err
 		}
 
-		if index == 0 {
-			line = bytes.TrimPrefix(line, []byte("\xef\xbb\xbf"))
+		var bom string
+		if index == 0 && strings.HasPrefix(line, kBOM) {
+			bom = kBOM
+			line = line[len(kBOM):]
 		}
 		index++
 
-		var sLine = strings.TrimSpace(string(line))
+		var lineNo = index
+		var raw = bom + line + eol
+		if this.preserveFormat && this.eol == "" {
+			this.eol = eol
+		}
+		var rawLine = line
+		var sLine = strings.TrimSpace(rawLine)
+		var indent = len(rawLine) - len(strings.TrimLeftFunc(rawLine, unicode.IsSpace))
 
 		// 如果是注释或者空行,则忽略
 		if sLine == "" {
+			if this.multiline && lastOption != nil {
+				// 多行值中间的空行, 之后的行仍然是该值的延续时作为值的一部分
+				blanks = append(blanks, raw)
+				continue
+			}
+			rawLines = append(rawLines, raw)
 			continue
 		}
 
 		if strings.HasPrefix(sLine, "#") || strings.HasPrefix(sLine, ";") {
 			comments = append(comments, strings.TrimSpace(sLine[1:]))
+			rawLines = append(rawLines, blanks...)
+			rawLines = append(rawLines, raw)
+			blanks = nil
+			lastOption = nil
 			continue
 		}
 
-		var sectionName = getSectionName(sLine)
-		if len(sectionName) > 0 && strings.ToLower(sectionName) != kDefaultSection {
+		if this.multiline && lastOption != nil && indent > lastIndent {
+			// 缩进的行是上一个值的延续
+			var value = lastOption.appendLine(lastIndex, strings.Repeat("\n", len(blanks))+sLine)
+			if lastLayout != nil {
+				if lastLayout.indent == "" {
+					lastLayout.indent = rawLine[:indent]
+				}
+				lastLayout.raw += strings.Join(blanks, "") + raw
+				lastLayout.value = value
+				lastLayout.suffix = rawLine[indent+len(sLine):]
+				lastLayout.eol = eol
+			}
+			blanks = nil
+			continue
+		}
+		lastOption = nil
+		rawLines = append(rawLines, blanks...)
+		blanks = nil
+
+		if strings.HasPrefix(sLine, "[") {
+			if end := strings.Index(sLine, "]"); end > 0 && len(this.inlineCommentMarkers) > 0 {
+				// section 行之后的行内注释
+				var rest = sLine[end+1:]
+				if i, _ := indexInlineComment(rest, false, this.inlineCommentMarkers); i >= 0 && strings.TrimSpace(rest[:i]) == "" {
+					sLine = sLine[:end+1]
+				}
+			}
+			var sectionName = getSectionName(sLine)
+			if len(sectionName) == 0 {
+				return newParseError(name, lineNo, rawLine, indent, ReasonInvalidSection)
+			}
+			if strings.ToLower(sectionName) == kDefaultSection {
+				sectionName = kDefaultSection
+			}
 			currentSection = this.newSection(sectionName)
-			currentSection.comments = append(currentSection.comments, comments...)
+			for _, c := range comments {
+				currentSection.AddComment(c)
+			}
 			comments = nil
+			currentSection.initOrigin(Origin{Source: name, Line: lineNo, Order: order})
+
+			if this.preserveFormat {
+				// 重复出现的 section 行作为新的一段, 写入时保持在源文件中的位置
+				currentLayout = &sectionLayout{section: currentSection, lead: rawLines, header: raw, comments: len(currentSection.Comments())}
+				this.layout = append(this.layout, currentLayout)
+				rawLines = nil
+			}
 			continue
 		}
 
+		// 以反斜杠结尾的行与下一行合并
+		for this.multiline && isContinued(rawLine) {
+			var next, nextEOL string
+			if next, nextEOL, err = readLine(reader); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return err
+			}
+			index++
+
+			rawLine = strings.TrimRightFunc(rawLine, unicode.IsSpace)
+			rawLine = rawLine[:len(rawLine)-1] + strings.TrimLeftFunc(next, unicode.IsSpace)
+			raw += next + nextEOL
+			eol = nextEOL
+		}
+		sLine = strings.TrimSpace(rawLine)
+
 		if currentSection == nil {
-			currentSection = this.newSection(strings.ToLower(kDefaultSection))
+			currentSection = this.newSection(kDefaultSection)
+			currentSection.initOrigin(Origin{Source: name, Line: lineNo, Order: order})
+			if this.preserveFormat {
+				currentLayout = &sectionLayout{section: currentSection}
+				this.layout = append(this.layout, currentLayout)
+			}
 		}
 
 		var optName, optIV, optValue = getOptionAndValue(sLine)
+		if optName == "" || !strings.HasPrefix(sLine, optName) {
+			return newParseError(name, lineNo, rawLine, indent, ReasonMissingKey)
+		}
 		optName = strings.TrimSpace(optName)
 		optIV = strings.TrimSpace(optIV)
 		optValue = strings.TrimSpace(optValue)
 
-		if optName != "" {
-			if this.uniqueOption && currentSection.HasOption(optName) {
-				return errors.New("有重复的 Option: " + optName)
+		var valueEnd = indent + len(sLine)
+		var valueStart = valueEnd - len(optValue)
+		var inlineComment string
+		if this.quoteValues && isQuoted(optValue) {
+			var value, n, ok = unquoteValue(optValue)
+			var rest = strings.TrimSpace(optValue[n:])
+			if ok && rest != "" {
+				rest, inlineComment = this.splitInlineComment(rest, true)
+				ok = rest == ""
 			}
+			if !ok {
+				return newParseError(name, lineNo, rawLine, valueStart, ReasonInvalidValue)
+			}
+			optValue = value
+			valueEnd = valueStart + n
+		} else if len(this.inlineCommentMarkers) > 0 {
+			var spaced = valueStart > 0 && unicode.IsSpace(rune(rawLine[valueStart-1]))
+			optValue, inlineComment = this.splitInlineComment(optValue, spaced)
+			valueEnd = valueStart + len(optValue)
+		}
 
-			var opt = currentSection.newOption(optName, optIV)
-			opt.AddValue(optValue)
-			opt.AddComment(comments...)
-			comments = nil
+		if this.uniqueOption && (currentSection.HasOption(optName) || this.base != nil && this.base.hasOption(currentSection.name, optName)) {
+			return newParseError(name, lineNo, rawLine, indent, ReasonDuplicateOption)
+		}
+
+		var opt = currentSection.newOption(optName, optIV)
+		var valueIndex = opt.addParsedValue(optValue, inlineComment, Origin{Source: name, Line: lineNo, Order: order})
+		opt.AddComment(comments...)
+		comments = nil
+
+		lastOption = opt
+		lastIndex = valueIndex
+		lastIndent = indent
+		lastLayout = nil
+
+		if this.preserveFormat {
+			var l = &lineLayout{}
+			l.before = rawLines
+			l.option = opt
+			l.index = valueIndex
+			l.raw = raw
+			l.prefix = bom + rawLine[:valueStart]
+			if optIV == "" {
+				l.prefix += " = "
+			}
+			l.value = optValue
+			l.suffix = rawLine[valueEnd:]
+			l.eol = eol
+			l.comment = opt.InlineComment()
+			l.comments = len(opt.Comments())
+			currentLayout.lines = append(currentLayout.lines, l)
+			rawLines = nil
+			lastLayout = l
+		}
+	}
+
+	if this.preserveFormat {
+		// 文件末尾的空行和注释作为单独的一段, 写入时保持在该文件的最后
+		rawLines = append(rawLines, blanks...)
+		if len(rawLines) > 0 {
+			this.layout = append(this.layout, &sectionLayout{lead: rawLines})
 		}
 	}
 	return nil
 }
 
-func (this *iniParser) WriteToFile(file string) error {
-	var f, err = os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_SYNC, os.ModePerm)
+// splitInlineComment 将 value 拆分为值和行内注释, 注释符号之前需要有空白字符, spaced 表示 value 之前是否为空白字符
+func (this *iniParser) splitInlineComment(value string, spaced bool) (string, string) {
+	var i, marker = indexInlineComment(value, spaced, this.inlineCommentMarkers)
+	if i < 0 {
+		return value, ""
+	}
+	return strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+len(marker):])
+}
+
+// indexInlineComment 返回 value 中第一个行内注释符号的位置和该符号, 没有时返回 -1
+func indexInlineComment(value string, spaced bool, markers []string) (int, string) {
+	for i := 0; i < len(value); i++ {
+		if (i == 0 && !spaced) || (i > 0 && value[i-1] != ' ' && value[i-1] != '\t' && value[i-1] != '\n') {
+			continue
+		}
+		for _, marker := range markers {
+			if strings.HasPrefix(value[i:], marker) {
+				return i, marker
+			}
+		}
+	}
+	return -1, ""
+}
+
+func isContinued(line string) bool {
+	return strings.HasSuffix(strings.TrimRightFunc(line, unicode.IsSpace), "\\")
+}
+
+// readLine 读取一行内容, 返回的 line 不包含换行符, eol 为该行原本的换行符
+func readLine(reader *bufio.Reader) (line, eol string, err error) {
+	line, err = reader.ReadString('\n')
 	if err != nil {
-		return err
+		if err != io.EOF || len(line) == 0 {
+			return "", "", err
+		}
+		err = nil
 	}
 
-	f.Truncate(0)
+	if strings.HasSuffix(line, "\n") {
+		line = line[:len(line)-1]
+		eol = "\n"
+		if strings.HasSuffix(line, "\r") {
+			line = line[:len(line)-1]
+			eol = "\r\n"
+		}
+	}
+	return line, eol, nil
+}
 
-	err = this.writeTo(f)
-	f.Close()
+func newParseError(source string, line int, text string, offset int, reason ParseErrorReason) *ParseError {
+	var err = &ParseError{}
+	err.Source = source
+	err.Line = line
+	err.Column = utf8.RuneCountInString(text[:offset]) + 1
+	err.Text = text
+	err.Reason = reason
 	return err
 }
 
-func (this *iniParser) writeTo(w io.Writer) error {
-	this.Lock()
-	defer this.Unlock()
-
-	var writer = bufio.NewWriter(w)
+// WriteTo 将配置写入 w, 格式与 WriteToFile 写入的文件相同, 返回写入的字节数
+func (this *iniParser) WriteTo(w io.Writer) (int64, error) {
+	this.RLock()
+	defer this.RUnlock()
 
-	for index, sectionName := range this.sectionKeys {
+	var writer = this.newWriter(w)
 
-		if index > 0 {
-			writer.WriteByte('\n')
+	var laidOut map[*Section]bool
+	var tail *sectionLayout
+	if this.preserveFormat {
+		var layouts = this.layout
+		if n := len(layouts); n > 0 && layouts[n-1].section == nil {
+			// 最后一个文件末尾的空行和注释写在新增的 section 之后
+			layouts, tail = layouts[:n-1], layouts[n-1]
 		}
+		laidOut = this.writeLayoutTo(writer, layouts)
+	}
 
+	var count = len(laidOut)
+	for _, sectionName := range this.sectionKeys {
 		var section = this.section(sectionName)
-		for _, c := range section.Comments() {
-			if len(strings.TrimSpace(c)) > 0 {
-				writer.WriteString(fmt.Sprintf("# %s\n", c))
-			}
+		if laidOut[section] {
+			continue
 		}
 
-		writer.WriteString(fmt.Sprintf("[%s]\n", sectionName))
+		if count > 0 {
+			writer.ensureEOL()
+			writer.writeText("\n")
+		}
+		count++
+		section.write(writer)
+	}
 
-		for _, optionKey := range section.optionKeys {
-			var opt = section.Option(optionKey)
-			if len(opt.Comments()) > 0 {
-				writer.WriteString("\n")
-			}
-			for _, c := range opt.Comments() {
-				if len(strings.TrimSpace(c)) > 0 {
-					writer.WriteString(fmt.Sprintf("# %s\n", c))
-				}
-			}
-			for _, value := range opt.values {
-				writer.WriteString(fmt.Sprintf("%s %s %s\n", opt.key, opt.iv, value))
+	if tail != nil {
+		writer.ensureEOL()
+		for _, line := range tail.lead {
+			writer.WriteString(line)
+		}
+	}
+	var err = writer.Flush()
+	if err == nil {
+		err = writer.err
+	}
+	return writer.written(), err
+}
+
+// String 返回 WriteTo 写入的内容
+func (this *iniParser) String() string {
+	return string(this.Bytes())
+}
+
+func (this *iniParser) Bytes() []byte {
+	var buf bytes.Buffer
+	this.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+func (this *iniParser) newWriter(w io.Writer) *iniWriter {
+	var writer = newIniWriter(w)
+	writer.quote = this.quoteValues
+	writer.multiline = this.multiline
+	if this.preserveFormat {
+		writer.newline = this.eol
+	}
+	writer.commentMarkers = this.inlineCommentMarkers
+	if len(this.inlineCommentMarkers) > 0 {
+		writer.commentMarker = this.inlineCommentMarkers[0]
+	}
+	return writer
+}
+
+func writeOption(writer *iniWriter, opt *Option) {
+	if len(opt.Comments()) > 0 {
+		writer.writeText("\n")
+	}
+	for _, c := range opt.Comments() {
+		if len(strings.TrimSpace(c)) > 0 {
+			writer.writeText(fmt.Sprintf("# %s\n", c))
+		}
+	}
+	for index, value := range opt.rawValues() {
+		var comment string
+		if index == 0 {
+			comment = writer.encodeComment(opt.InlineComment())
+		}
+
+		value = writer.encodeValue(value, "", "\n")
+		if opt.iv == "" {
+			if value == "" && comment == "" {
+				writer.writeText(fmt.Sprintf("%s\n", opt.key))
+				continue
 			}
+			writer.writeText(fmt.Sprintf("%s = %s%s\n", opt.key, value, comment))
+			continue
 		}
+		writer.writeText(fmt.Sprintf("%s %s %s%s\n", opt.key, opt.iv, value, comment))
 	}
-	return writer.Flush()
 }
 
 func (this *iniParser) Reset() {
 	this.Lock()
-	this.Unlock()
+	defer this.Unlock()
 	this.init()
 }
 
@@ -285,8 +721,10 @@ func (this *iniParser) newSection(name string) *Section {
 	var section, _ = this.sections.Load(name)
 	if section == nil {
 		section = NewSection(name)
+		section.(*Section).parser = this
 		this.sections.Store(name, section)
 		this.sectionKeys = append(this.sectionKeys, name)
+		this.modified()
 	}
 	return section.(*Section)
 }
@@ -308,15 +746,25 @@ func (this *iniParser) MustSection(name string) *Section {
 
 func (this *iniParser) section(name string) *Section {
 	var s, _ = this.sections.Load(name)
+	if s == nil {
+		return nil
+	}
 	return s.(*Section)
 }
 
+// Section 返回名称为 name 的 section, 不存在时返回 nil
 func (this *iniParser) Section(name string) *Section {
 	this.RLock()
 	defer this.RUnlock()
 	return this.section(name)
 }
 
+// LookupSection 返回名称为 name 的 section, 不存在时返回 nil 和 false
+func (this *iniParser) LookupSection(name string) (*Section, bool) {
+	var s = this.Section(name)
+	return s, s != nil
+}
+
 func (this *iniParser) SectionNames() []string {
 	this.RLock()
 	defer this.RUnlock()
@@ -352,9 +800,17 @@ func (this *iniParser) RemoveSection(section string) {
 	this.Lock()
 	defer this.Unlock()
 
+	this.removeSection(section)
+}
+
+func (this *iniParser) removeSection(section string) {
 	if strings.ToLower(section) == kDefaultSection {
 		return
 	}
+	this.deleteSection(section)
+}
+
+func (this *iniParser) deleteSection(section string) {
 	this.sections.Delete(section)
 
 	var index = -1
@@ -368,6 +824,7 @@ func (this *iniParser) RemoveSection(section string) {
 	if index >= 0 {
 		this.sectionKeys = append(this.sectionKeys[0:index], this.sectionKeys[index+1:]...)
 	}
+	this.modified()
 }
 
 func (this *iniParser) mustOption(section, option string) *Option {
@@ -391,12 +848,22 @@ func (this *iniParser) option(section, option string) *Option {
 	return nil
 }
 
+// Option 返回 section 中的 option, 不存在时返回 nil
 func (this *iniParser) Option(section, option string) *Option {
-	this.Lock()
-	defer this.Unlock()
+	this.RLock()
+	defer this.RUnlock()
 	return this.option(section, option)
 }
 
+// Lookup 返回 section 中 option 替换变量之后的值, option 不存在时返回 false
+func (this *iniParser) Lookup(section, option string) (string, bool) {
+	var opt = this.Option(section, option)
+	if opt == nil {
+		return "", false
+	}
+	return opt.Value(), true
+}
+
 func (this *iniParser) Options(section string) []string {
 	this.RLock()
 	defer this.RUnlock()
@@ -423,10 +890,12 @@ func (this *iniParser) HasOption(section, option string) bool {
 	this.RLock()
 	defer this.RUnlock()
 
+	return this.hasOption(section, option)
+}
+
+func (this *iniParser) hasOption(section, option string) bool {
 	if s, ok := this.sections.Load(section); ok {
-		if _, ok := s.(*Section).options.Load(option); ok {
-			return true
-		}
+		return s.(*Section).HasOption(option)
 	}
 	return false
 }
@@ -481,7 +950,10 @@ func (this *iniParser) GetValue(section, option string) string {
 func (this *iniParser) MustValue(section, option, defaultValue string) string {
 	this.RLock()
 	defer this.RUnlock()
-	var opt = this.mustOption(section, option)
+	var opt, err = this.lookupOption(section, option)
+	if err != nil {
+		return defaultValue
+	}
 	return opt.MustString(defaultValue)
 }
 
@@ -489,7 +961,10 @@ func (this *iniParser) MustInt(section, option string, defaultValue int) int {
 	this.RLock()
 	defer this.RUnlock()
 
-	var opt = this.mustOption(section, option)
+	var opt, err = this.lookupOption(section, option)
+	if err != nil {
+		return defaultValue
+	}
 	return opt.MustInt(defaultValue)
 }
 
@@ -497,7 +972,10 @@ func (this *iniParser) MustInt64(section, option string, defaultValue int64) int
 	this.RLock()
 	defer this.RUnlock()
 
-	var opt = this.mustOption(section, option)
+	var opt, err = this.lookupOption(section, option)
+	if err != nil {
+		return defaultValue
+	}
 	return opt.MustInt64(defaultValue)
 }
 
@@ -505,7 +983,10 @@ func (this *iniParser) MustFloat32(section, option string, defaultValue float32)
 	this.RLock()
 	defer this.RUnlock()
 
-	var opt = this.mustOption(section, option)
+	var opt, err = this.lookupOption(section, option)
+	if err != nil {
+		return defaultValue
+	}
 	return opt.MustFloat32(defaultValue)
 }
 
@@ -513,7 +994,10 @@ func (this *iniParser) MustFloat64(section, option string, defaultValue float64)
 	this.RLock()
 	defer this.RUnlock()
 
-	var opt = this.mustOption(section, option)
+	var opt, err = this.lookupOption(section, option)
+	if err != nil {
+		return defaultValue
+	}
 	return opt.MustFloat64(defaultValue)
 }
 
@@ -521,7 +1005,10 @@ func (this *iniParser) MustBool(section, option string, defaultValue bool) bool
 	this.RLock()
 	defer this.RUnlock()
 
-	var opt = this.mustOption(section, option)
+	var opt, err = this.lookupOption(section, option)
+	if err != nil {
+		return defaultValue
+	}
 	return opt.MustBool(defaultValue)
 }
 
@@ -529,6 +1016,10 @@ func (this *iniParser) GetValues(section, option string) []string {
 	this.RLock()
 	defer this.RUnlock()
 
+	return this.values(section, option)
+}
+
+func (this *iniParser) values(section, option string) []string {
 	var s, _ = this.sections.Load(section)
 	if s != nil {
 		var opt, _ = s.(*Section).options.Load(option)
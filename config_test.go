@@ -1,8 +1,14 @@
 package ini4go
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -53,6 +59,427 @@ func TestLoadFile(t *testing.T) {
 	fmt.Println(r.GetValues("s1", "sk4"))
 }
 
+func TestParseError(t *testing.T) {
+	var r = New(false)
+	var err = r.LoadString("broken.conf", "[s1]\nk1 = v1\n  = v2\n")
+
+	var pErr *ParseError
+	if !errors.As(err, &pErr) {
+		t.Fatal("应该返回 *ParseError")
+	}
+	if pErr.Source != "broken.conf" || pErr.Line != 3 || pErr.Column != 3 || pErr.Reason != ReasonMissingKey {
+		t.Error("ParseError 的位置信息不正确", pErr)
+	}
+
+	r = New(false)
+	r.SetUniqueOption(true)
+	err = r.LoadString("dup.conf", "[s1]\nk1 = v1\nk1 = v2\n")
+	if !errors.As(err, &pErr) || pErr.Reason != ReasonDuplicateOption || pErr.Line != 3 {
+		t.Error("应该返回重复 Option 的 ParseError", err)
+	}
+
+	r = New(false)
+	err = r.LoadString("section.conf", "[s1\nk1 = v1\n")
+	if !errors.As(err, &pErr) || pErr.Reason != ReasonInvalidSection || pErr.Line != 1 {
+		t.Error("应该返回无效 Section 的 ParseError", err)
+	}
+
+	// 出错时不会加载文件中的任何内容
+	r = New(false)
+	r.LoadString("app.conf", "[s0]\nk0 = v0\n")
+	var before = r.String()
+	if err = r.LoadString("broken.conf", "[s1]\nk1 = v1\n[bad\n"); err == nil {
+		t.Fatal("应该返回 ParseError")
+	}
+	if r.HasSection("s1") || r.String() != before {
+		t.Errorf("出错时配置应该保持不变:\n%s", r.String())
+	}
+
+	r.SetUniqueOption(true)
+	if err = r.LoadString("dup.conf", "[s1]\nk1 = v1\n[s0]\nk0 = v1\n"); !errors.As(err, &pErr) || pErr.Reason != ReasonDuplicateOption || pErr.Line != 4 {
+		t.Error("与已有的 option 重复时应该返回 ParseError", err)
+	}
+	if r.HasSection("s1") || r.String() != before {
+		t.Errorf("出错时配置应该保持不变:\n%s", r.String())
+	}
+
+	var fsys = fstest.MapFS{
+		"a.conf": {Data: []byte("[s1]\nk1 = v1\n")},
+		"b.conf": {Data: []byte("[bad\n")},
+	}
+	if err = r.LoadFS(fsys); err == nil || r.HasSection("s1") {
+		t.Error("多个文件中有一个出错时不应该加载任何文件", err)
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	var fsys = fstest.MapFS{
+		"app.conf":      {Data: []byte("[s1]\nk1 = v1\n")},
+		"conf.d/db.ini": {Data: []byte("[db]\nhost = localhost\n")},
+		"readme.txt":    {Data: []byte("not a config")},
+	}
+
+	var r = New(false)
+	if err := r.LoadFS(fsys, "*.conf", "conf.d/*.ini"); err != nil {
+		t.Fatal(err)
+	}
+	if r.GetValue("s1", "k1") != "v1" || r.GetValue("db", "host") != "localhost" {
+		t.Error("LoadFS 没有加载匹配的文件")
+	}
+	if r.HasSection("readme.txt") {
+		t.Error("LoadFS 不应该加载未匹配的文件")
+	}
+
+	if err := r.LoadString("inline", "[s2]\nk2 = v2\n"); err != nil {
+		t.Fatal(err)
+	}
+	if r.GetValue("s2", "k2") != "v2" {
+		t.Error("s2 -> k2 应该为 v2")
+	}
+}
+
+func TestPreserveFormat(t *testing.T) {
+	var src = "; 全局配置\r\n" +
+		"name=app\r\n" +
+		"\r\n" +
+		"[s1]   \r\n" +
+		"  # 缩进的注释\r\n" +
+		"  k1   :   v1\r\n" +
+		"k2=v2\r\n" +
+		"k1 = v3\r\n" +
+		"\r\n" +
+		"[s2]\r\n" +
+		"; 将被删除\r\n" +
+		"k3 = v3\r\n" +
+		"k4 = v4\r\n" +
+		"# end"
+
+	var r = New(false)
+	r.SetPreserveFormat(true)
+	if err := r.LoadString("preserve.conf", src); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+	if buf.String() != src {
+		t.Errorf("未修改时输出应该与原文一致:\n%q", buf.String())
+	}
+
+	r.SetValue("s1", "k2", "new")
+	r.RemoveOption("s2", "k3")
+	r.MustOption("s1", "k1").AddValue("v5")
+	r.SetValue("s2", "k5", "v5")
+	r.SetValue("s3", "k6", "v6")
+
+	var expected = "; 全局配置\r\n" +
+		"name=app\r\n" +
+		"\r\n" +
+		"[s1]   \r\n" +
+		"  # 缩进的注释\r\n" +
+		"  k1   :   v1\r\n" +
+		"k2=new\r\n" +
+		"k1 = v3\r\n" +
+		"k1 = v5\r\n" +
+		"\r\n" +
+		"[s2]\r\n" +
+		"k4 = v4\r\n" +
+		"k5 = v5\r\n" +
+		"\r\n" +
+		"[s3]\r\n" +
+		"k6 = v6\r\n" +
+		"# end"
+
+	buf.Reset()
+	r.WriteTo(&buf)
+	if buf.String() != expected {
+		t.Errorf("只应该修改变动的行:\n%q", buf.String())
+	}
+
+	// 重复出现的 section 保持在源文件中的位置, 新增的 option 写在最后一段
+	for _, src := range []string{
+		"[s]\nb=2\n\n[t]\nc=3\n[s]\nd=4\n",
+		"a=1\n\n[t]\nc=3\n\n[default]\nb=2\n",
+	} {
+		r = New(false)
+		r.SetPreserveFormat(true)
+		r.LoadString("repeated.conf", src)
+		if r.String() != src {
+			t.Errorf("未修改时输出应该与原文一致:\n%q", r.String())
+		}
+	}
+	r.SetValue("default", "e", "5")
+	if r.String() != "a=1\n\n[t]\nc=3\n\n[default]\nb=2\ne = 5\n" {
+		t.Errorf("新增的 option 应该写在最后一段:\n%q", r.String())
+	}
+
+	// 加载之后添加的注释
+	r = New(false)
+	r.SetPreserveFormat(true)
+	r.LoadString("comment.conf", "# s1\n[s1]\n; k1\nk1 = v1\nk2 = v2\n")
+	r.Section("s1").AddComment("hello")
+	r.Option("s1", "k1").AddComment("k1 hello")
+	r.Option("s1", "k2").AddComment("k2 hello")
+	if r.String() != "# s1\n# hello\n[s1]\n; k1\n# k1 hello\nk1 = v1\n# k2 hello\nk2 = v2\n" {
+		t.Errorf("加载之后添加的注释应该写入:\n%q", r.String())
+	}
+
+	// 每个文件末尾的注释保持在该文件的最后
+	r = New(false)
+	r.SetPreserveFormat(true)
+	r.LoadString("a.conf", "[a]\nk=1\n# trailing A\n")
+	r.LoadString("b.conf", "[b]\nk=2\n# trailing B")
+	if r.String() != "[a]\nk=1\n# trailing A\n[b]\nk=2\n# trailing B" {
+		t.Errorf("文件末尾的注释应该保持在该文件的最后:\n%q", r.String())
+	}
+	r.SetValue("a", "k2", "3")
+	r.SetValue("c", "k", "4")
+	if r.String() != "[a]\nk=1\nk2 = 3\n# trailing A\n[b]\nk=2\n\n[c]\nk = 4\n# trailing B" {
+		t.Errorf("新增的 option 和 section 应该写在注释之前:\n%q", r.String())
+	}
+}
+
+func TestMultiline(t *testing.T) {
+	var src = "[s1]\n" +
+		"hosts = h1,\n" +
+		"    h2,\n" +
+		"    h3\n" +
+		"sql = select * \\\n" +
+		"      from t\n" +
+		"k1 = v1\n"
+
+	var r = New(false)
+	r.SetMultiline(true)
+	if err := r.LoadString("multiline.conf", src); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.GetValue("s1", "hosts") != "h1,\nh2,\nh3" {
+		t.Errorf("hosts 应该为多行的值: %q", r.GetValue("s1", "hosts"))
+	}
+	if r.GetValue("s1", "sql") != "select * from t" {
+		t.Errorf("sql 应该与下一行合并: %q", r.GetValue("s1", "sql"))
+	}
+	if r.GetValue("s1", "k1") != "v1" {
+		t.Error("s1 -> k1 应该为 v1")
+	}
+
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+
+	var r2 = New(false)
+	r2.SetMultiline(true)
+	if err := r2.LoadString("output.conf", buf.String()); err != nil {
+		t.Fatal(err)
+	}
+	if r2.GetValue("s1", "hosts") != r.GetValue("s1", "hosts") || r2.GetValue("s1", "sql") != r.GetValue("s1", "sql") {
+		t.Errorf("多行的值写入之后应该能够重新读取:\n%s", buf.String())
+	}
+
+	var p = New(false)
+	p.SetMultiline(true)
+	p.SetPreserveFormat(true)
+	p.LoadString("multiline.conf", src)
+	buf.Reset()
+	p.WriteTo(&buf)
+	if buf.String() != src {
+		t.Errorf("未修改时输出应该与原文一致:\n%q", buf.String())
+	}
+
+	p.SetValue("s1", "hosts", "h4\nh5")
+	buf.Reset()
+	p.WriteTo(&buf)
+	if !strings.HasPrefix(buf.String(), "[s1]\nhosts = h4\n    h5\nsql") {
+		t.Errorf("修改后的多行值应该保留原有的缩进:\n%q", buf.String())
+	}
+
+	// 包含空行的多行值
+	r.SetValue("s1", "hosts", "a\n\nb")
+	buf.Reset()
+	r.WriteTo(&buf)
+	r2 = New(false)
+	r2.SetMultiline(true)
+	if err := r2.LoadString("output.conf", buf.String()); err != nil {
+		t.Fatal(err)
+	}
+	if r2.GetValue("s1", "hosts") != "a\n\nb" || fmt.Sprint(r2.Options("s1")) != "[hosts sql k1]" {
+		t.Errorf("包含空行的多行值写入之后应该能够重新读取: %q\n%s", r2.GetValue("s1", "hosts"), buf.String())
+	}
+
+	src = "[s1]\nk1 = a\n\n    b\n\nk2 = c\n\n"
+	p = New(false)
+	p.SetMultiline(true)
+	p.SetPreserveFormat(true)
+	p.LoadString("multiline.conf", src)
+	if p.GetValue("s1", "k1") != "a\n\nb" || p.GetValue("s1", "k2") != "c" {
+		t.Errorf("值中间的空行应该属于该值: %q", p.GetValue("s1", "k1"))
+	}
+	buf.Reset()
+	p.WriteTo(&buf)
+	if buf.String() != src {
+		t.Errorf("未修改时输出应该与原文一致:\n%q", buf.String())
+	}
+
+	// 以反斜杠结尾、以注释符号开头的行需要加上引号才能原样读取
+	for _, value := range []string{`C:\dir\`, "a\n#b", "a\n; b"} {
+		r = New(false)
+		r.SetMultiline(true)
+		r.SetValue("s1", "k1", value)
+		r.SetValue("s1", "k2", "next")
+		buf.Reset()
+		if _, err := r.WriteTo(&buf); err == nil {
+			t.Errorf("没有开启引号时 %q 应该返回错误", value)
+		}
+
+		r.SetQuoteValues(true)
+		buf.Reset()
+		if _, err := r.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		r2 = New(false)
+		r2.SetMultiline(true)
+		r2.SetQuoteValues(true)
+		if err := r2.LoadString("output.conf", buf.String()); err != nil {
+			t.Fatal(err)
+		}
+		if r2.GetValue("s1", "k1") != value || r2.GetValue("s1", "k2") != "next" {
+			t.Errorf("%q 写入之后应该能够重新读取:\n%s", value, buf.String())
+		}
+	}
+}
+
+func TestQuoteValues(t *testing.T) {
+	var src = `[s1]
+k1 = "  有空格  "
+k2 = 'a # b'
+k3 = "line1\nline2\t\"\u4e2d\\"
+k4 = plain "text"
+`
+	var r = New(false)
+	r.SetQuoteValues(true)
+	if err := r.LoadString("quote.conf", src); err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = map[string]string{
+		"k1": "  有空格  ",
+		"k2": "a # b",
+		"k3": "line1\nline2\t\"中\\",
+		"k4": `plain "text"`,
+	}
+	for key, value := range expected {
+		if r.GetValue("s1", key) != value {
+			t.Errorf("s1 -> %s 应该为 %q, 实际为 %q", key, value, r.GetValue("s1", key))
+		}
+	}
+
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+
+	var r2 = New(false)
+	r2.SetQuoteValues(true)
+	if err := r2.LoadString("output.conf", buf.String()); err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range expected {
+		if r2.GetValue("s1", key) != value {
+			t.Errorf("写入之后 s1 -> %s 应该为 %q:\n%s", key, value, buf.String())
+		}
+	}
+
+	var pErr *ParseError
+	var err = New(false).LoadString("bad.conf", "k = \"abc")
+	if err != nil {
+		t.Error("未开启引号时不应该返回错误", err)
+	}
+	r = New(false)
+	r.SetQuoteValues(true)
+	err = r.LoadString("bad.conf", "k = \"abc")
+	if !errors.As(err, &pErr) || pErr.Reason != ReasonInvalidValue || pErr.Column != 5 {
+		t.Error("引号不完整应该返回 ParseError", err)
+	}
+}
+
+func TestInlineComment(t *testing.T) {
+	var src = "[s1]\ntimeout = 30 ; seconds\nurl = http://host/#anchor\nname = \"a ; b\" # quoted\n"
+
+	var r = New(false)
+	if r.LoadString("inline.conf", src); r.GetValue("s1", "timeout") != "30 ; seconds" {
+		t.Error("默认不应该处理行内注释")
+	}
+
+	r = New(false)
+	r.SetQuoteValues(true)
+	r.SetInlineCommentMarkers(";", "#")
+	if err := r.LoadString("inline.conf", src); err != nil {
+		t.Fatal(err)
+	}
+	if r.MustInt("s1", "timeout", 0) != 30 || r.MustOption("s1", "timeout").InlineComment() != "seconds" {
+		t.Error("s1 -> timeout 应该为 30, 注释为 seconds")
+	}
+	if r.GetValue("s1", "url") != "http://host/#anchor" {
+		t.Error("注释符号之前没有空白字符时不是注释")
+	}
+	if r.GetValue("s1", "name") != "a ; b" || r.MustOption("s1", "name").InlineComment() != "quoted" {
+		t.Error("引号中的注释符号不是注释")
+	}
+
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+	if !strings.Contains(buf.String(), "timeout = 30 ; seconds\n") {
+		t.Errorf("行内注释应该写在值的后面:\n%s", buf.String())
+	}
+
+	var p = New(false)
+	p.SetPreserveFormat(true)
+	p.SetInlineCommentMarkers(";")
+	p.LoadString("inline.conf", "[s1]\ntimeout=30   ;   seconds\n")
+	p.SetValue("s1", "timeout", "60")
+	buf.Reset()
+	p.WriteTo(&buf)
+	if buf.String() != "[s1]\ntimeout=60   ;   seconds\n" {
+		t.Errorf("修改值时应该保留行内注释: %q", buf.String())
+	}
+
+	p.MustOption("s1", "timeout").AddValue("90")
+	buf.Reset()
+	p.WriteTo(&buf)
+	if buf.String() != "[s1]\ntimeout=60   ;   seconds\ntimeout=90\n" {
+		t.Errorf("新增的值不应该复制行内注释: %q", buf.String())
+	}
+
+	// 包含行内注释符号的值
+	r = New(false)
+	r.SetQuoteValues(true)
+	r.SetInlineCommentMarkers("//")
+	r.SetValue("s1", "k1", "a // b")
+	var r2 = New(false)
+	r2.SetQuoteValues(true)
+	r2.SetInlineCommentMarkers("//")
+	if err := r2.LoadString("output.conf", r.String()); err != nil || r2.GetValue("s1", "k1") != "a // b" {
+		t.Errorf("包含行内注释符号的值应该加上引号:\n%s", r.String())
+	}
+
+	r = New(false)
+	r.SetInlineCommentMarkers(";")
+	r.SetValue("s1", "k1", "a ; b")
+	if _, err := r.WriteTo(&buf); err == nil {
+		t.Error("没有开启引号时, 包含行内注释符号的值应该返回错误")
+	}
+
+	// section 行之后的行内注释
+	p = New(false)
+	p.SetPreserveFormat(true)
+	p.SetInlineCommentMarkers(";")
+	src = "[s1] ; main\nk1 = v1\n"
+	if err := p.LoadString("inline.conf", src); err != nil || p.GetValue("s1", "k1") != "v1" {
+		t.Error("section 行之后可以有行内注释", err)
+	}
+	if p.String() != src {
+		t.Errorf("未修改时输出应该与原文一致:\n%q", p.String())
+	}
+}
+
 func TestOutput(t *testing.T) {
 	var r = New(false)
 	r.SetValue("s1", "p1", "v1")
@@ -100,3 +527,225 @@ func TestAppend(t *testing.T) {
 //		}
 //	}
 //}
+
+func TestOrigin(t *testing.T) {
+	var r = New(false)
+	r.LoadString("a.conf", "[s1]\nk1 = v1\nk2 = v2\n")
+	r.LoadString("b.conf", "\n[s1]\nk1 = v3\n")
+	r.SetValue("s1", "k2", "set")
+
+	var opt = r.MustOption("s1", "k1")
+	if opt.Origin() != (Origin{Source: "a.conf", Line: 2, Order: 1}) {
+		t.Error("s1 -> k1 的第一个值来自 a.conf:2", opt.Origin())
+	}
+	if opt.OriginAt(1) != (Origin{Source: "b.conf", Line: 3, Order: 2}) {
+		t.Error("s1 -> k1 的第二个值来自 b.conf:3", opt.OriginAt(1))
+	}
+	if !r.MustOption("s1", "k2").Origin().IsZero() {
+		t.Error("通过 SetValue 设置的值没有来源")
+	}
+	if r.Section("s1").Origin().Line != 1 {
+		t.Error("s1 来自 a.conf:1", r.Section("s1").Origin())
+	}
+
+	var buf bytes.Buffer
+	r.Dump(&buf)
+	var expected = "[s1] ; a.conf:1 (#1)\n" +
+		"k1 = v1 ; a.conf:2 (#1)\n" +
+		"k1 = v3 ; b.conf:3 (#2)\n" +
+		"k2 = set ; -\n"
+	if buf.String() != expected {
+		t.Errorf("Dump 输出错误:\n%s", buf.String())
+	}
+}
+
+func TestTypedGetters(t *testing.T) {
+	var r = New(false)
+	r.LoadString("app.conf", "[s1]\nport = 8080\nrate = 0.5\ndebug = on\nbad = abc\nday = 2020-06-06\n")
+
+	if v, err := r.Int("s1", "port"); err != nil || v != 8080 {
+		t.Error("s1 -> port 应该为 8080", err)
+	}
+	if v, err := r.Float64("s1", "rate"); err != nil || v != 0.5 {
+		t.Error("s1 -> rate 应该为 0.5", err)
+	}
+	if v, err := r.Bool("s1", "debug"); err != nil || !v {
+		t.Error("s1 -> debug 应该为 true", err)
+	}
+	if v, err := r.TimeWithLayout("s1", "day", "2006-01-02"); err != nil || v.Day() != 6 {
+		t.Error("s1 -> day 应该为 2020-06-06", err)
+	}
+
+	var _, err = r.Int("missing", "port")
+	if !errors.Is(err, ErrSectionNotFound) {
+		t.Error("应该返回 ErrSectionNotFound", err)
+	}
+	_, err = r.Int("s1", "missing")
+	if !errors.Is(err, ErrOptionNotFound) {
+		t.Error("应该返回 ErrOptionNotFound", err)
+	}
+	_, err = r.Int("s1", "bad")
+	var vErr *ValueError
+	if !errors.Is(err, ErrInvalidValue) || !errors.As(err, &vErr) || vErr.Value != "abc" {
+		t.Error("应该返回 ErrInvalidValue", err)
+	}
+
+	if r.HasSection("missing") || r.HasOption("s1", "missing") {
+		t.Error("读取不存在的 option 不应该修改配置")
+	}
+}
+
+func TestReadWithoutSideEffect(t *testing.T) {
+	var r = New(false)
+	r.LoadString("app.conf", "[s1]\nk1 = v1\nk2 = %(missing)s\n")
+
+	var before bytes.Buffer
+	r.WriteTo(&before)
+	var sectionNames = r.SectionNames()
+
+	r.GetValue("s2", "k1")
+	r.GetValue("s1", "missing")
+	r.MustValue("s3", "k1", "default")
+	r.MustInt("s1", "k3", 1)
+	r.MustInt64("s1", "k3", 1)
+	r.MustFloat32("s1", "k3", 1)
+	r.MustFloat64("s1", "k3", 1)
+	r.MustBool("s4", "k3", true)
+	r.GetValues("s5", "k1")
+	r.GetValue("s1", "k2")
+	r.Int("s6", "k1")
+	r.HasOption("s7", "k1")
+
+	var after bytes.Buffer
+	r.WriteTo(&after)
+	if before.String() != after.String() {
+		t.Errorf("读取不应该修改配置:\n%s", after.String())
+	}
+	if fmt.Sprint(r.SectionNames()) != fmt.Sprint(sectionNames) {
+		t.Error("读取不应该创建新的 section", r.SectionNames())
+	}
+	if len(r.Options("s1")) != 2 {
+		t.Error("读取不应该创建新的 option", r.Options("s1"))
+	}
+}
+
+func TestNilSafeLookup(t *testing.T) {
+	var r = New(false)
+	r.LoadString("app.conf", "[s1]\nk1 = v1\n")
+
+	if r.Section("missing") != nil || r.Option("missing", "k1") != nil || r.Option("s1", "missing") != nil {
+		t.Error("不存在的 section 和 option 应该返回 nil")
+	}
+	if r.Section("s1").Option("missing") != nil {
+		t.Error("不存在的 option 应该返回 nil")
+	}
+	if _, ok := r.LookupSection("missing"); ok {
+		t.Error("不存在的 section")
+	}
+	if s, ok := r.LookupSection("s1"); !ok || s.Name() != "s1" {
+		t.Error("s1 应该存在")
+	}
+	if _, ok := r.Section("s1").LookupOption("missing"); ok {
+		t.Error("不存在的 option")
+	}
+	if v, ok := r.Lookup("s1", "k1"); !ok || v != "v1" {
+		t.Error("s1 -> k1 应该为 v1")
+	}
+	if _, ok := r.Lookup("s1", "missing"); ok {
+		t.Error("不存在的 option")
+	}
+	if r.Options("missing") != nil || r.OptionList("missing") != nil {
+		t.Error("不存在的 section 应该返回 nil")
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	var r = New(true)
+	r.LoadString("app.conf", "[s1]\nk1 = v1\nk2 = %(k1)s-2\n")
+
+	var section = r.MustSection("s1")
+	var opt = r.MustOption("s1", "k1")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				var key = fmt.Sprintf("k%d-%d", i, j%10)
+				section.NewOption(key, "=", []string{"v"}, nil)
+				section.MustOption(key).AddValue(fmt.Sprint(j))
+				opt.SetValue(fmt.Sprint(j))
+				opt.SetInlineComment("inline")
+				r.SetValue("s2", key, "v")
+				if j%50 == 0 {
+					opt.AddComment("comment")
+					section.AddComment("comment")
+				}
+				if j%3 == 0 {
+					section.RemoveOption(key)
+					r.RemoveOption("s2", key)
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				opt.Value()
+				opt.Values()
+				opt.Comments()
+				opt.InlineComment()
+				opt.Origin()
+				r.MustOption("s1", "k2").Value()
+				r.MustValue("s1", "k2", "")
+				section.OptionKeys()
+				section.OptionList()
+				section.Comments()
+				r.Options("s2")
+				r.WriteTo(io.Discard)
+				r.Dump(io.Discard)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v := r.MustValue("s1", "k2", ""); !strings.HasSuffix(v, "-2") {
+		t.Error("s1 -> k2 应该以 -2 结尾", v)
+	}
+	if len(section.OptionKeys()) != len(section.OptionList()) {
+		t.Error("OptionKeys 与 OptionList 不一致", section.OptionKeys())
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	var src = "# 服务\n[server]\nhost = a\n\n[db]\nhost = h1\nhost = h2\n"
+	var r = New(true)
+	r.LoadString("app.conf", src)
+
+	var _ io.WriterTo = r
+	var _ fmt.Stringer = r
+
+	var buf bytes.Buffer
+	var n, err = r.WriteTo(&buf)
+	if err != nil || n != int64(len(src)) || buf.String() != src {
+		t.Errorf("WriteTo 输出错误 %d %v:\n%s", n, err, buf.String())
+	}
+	if r.String() != src || string(r.Bytes()) != src {
+		t.Errorf("String 输出错误:\n%s", r.String())
+	}
+
+	buf.Reset()
+	if n, err = r.Section("db").WriteTo(&buf); err != nil || n != int64(buf.Len()) || buf.String() != "[db]\nhost = h1\nhost = h2\n" {
+		t.Errorf("Section.WriteTo 输出错误 %d %v:\n%s", n, err, buf.String())
+	}
+
+	r.SetPreserveFormat(true)
+	r.Reset()
+	r.LoadString("app.conf", "[db]\n  host=h1 \n")
+	r.SetValue("db", "port", "3306")
+	buf.Reset()
+	r.Section("db").WriteTo(&buf)
+	if buf.String() != "[db]\n  host=h1 \nport = 3306\n" {
+		t.Errorf("Section.WriteTo 应该保留格式:\n%s", buf.String())
+	}
+}
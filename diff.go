@@ -0,0 +1,267 @@
+package ini4go
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type DiffKind int
+
+const (
+	DiffAdded DiffKind = iota
+	DiffRemoved
+	DiffChanged
+)
+
+func (this DiffKind) String() string {
+	switch this {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("DiffKind(%d)", int(this))
+}
+
+func (this DiffKind) MarshalText() ([]byte, error) {
+	return []byte(this.String()), nil
+}
+
+func (this *DiffKind) UnmarshalText(text []byte) error {
+	for _, kind := range []DiffKind{DiffAdded, DiffRemoved, DiffChanged} {
+		if kind.String() == string(text) {
+			*this = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("ini4go: 无效的 DiffKind %q", text)
+}
+
+// OptionDiff 描述 option 的变化, Old 和 New 为变化前后的原始值, 新增的 option 没有 Old, 删除的 option 没有 New
+type OptionDiff struct {
+	Option string   `json:"option"`
+	Kind   DiffKind `json:"kind"`
+	Old    []string `json:"old,omitempty"`
+	New    []string `json:"new,omitempty"`
+}
+
+// SectionDiff 描述 section 的变化, 新增和删除的 section 包含其中所有的 option
+type SectionDiff struct {
+	Section string       `json:"section"`
+	Kind    DiffKind     `json:"kind"`
+	Options []OptionDiff `json:"options,omitempty"`
+}
+
+// Difference 是 Diff 的结果, 按照 section 和 option 的顺序排列, 先列出 a 中的再列出 b 中新增的;
+// 通过 Apply 可以将其作为补丁应用到 a 上得到 b
+type Difference struct {
+	Sections []SectionDiff `json:"sections"`
+
+	// b 中 section 和 option 的顺序, 应用补丁之后按照该顺序排列
+	SectionOrder []string            `json:"sectionOrder,omitempty"`
+	OptionOrder  map[string][]string `json:"optionOrder,omitempty"`
+}
+
+// Diff 比较两个配置中的 section 和 option 的原始值, 不比较注释和格式
+func Diff(a, b *Ini) *Difference {
+	// 先复制, 避免同时持有两个配置的锁
+	var from, to = a.Clone(), b.Clone()
+
+	var d = &Difference{}
+	d.SectionOrder = to.sectionKeys
+	d.OptionOrder = make(map[string][]string)
+
+	for _, name := range from.sectionKeys {
+		var s = from.section(name)
+		var ns = to.section(name)
+		if ns == nil {
+			d.Sections = append(d.Sections, SectionDiff{Section: name, Kind: DiffRemoved, Options: optionDiffs(s, DiffRemoved)})
+			continue
+		}
+
+		var options []OptionDiff
+		for _, opt := range s.orderedOptions() {
+			var nOpt = ns.Option(opt.key)
+			if nOpt == nil {
+				options = append(options, OptionDiff{Option: opt.key, Kind: DiffRemoved, Old: opt.values})
+			} else if !reflect.DeepEqual(opt.values, nOpt.values) {
+				options = append(options, OptionDiff{Option: opt.key, Kind: DiffChanged, Old: opt.values, New: nOpt.values})
+			}
+		}
+		for _, nOpt := range ns.orderedOptions() {
+			if !s.HasOption(nOpt.key) {
+				options = append(options, OptionDiff{Option: nOpt.key, Kind: DiffAdded, New: nOpt.values})
+			}
+		}
+
+		if len(options) > 0 {
+			d.Sections = append(d.Sections, SectionDiff{Section: name, Kind: DiffChanged, Options: options})
+			d.OptionOrder[name] = ns.optionKeys
+		}
+	}
+
+	for _, name := range to.sectionKeys {
+		if from.section(name) == nil {
+			var ns = to.section(name)
+			d.Sections = append(d.Sections, SectionDiff{Section: name, Kind: DiffAdded, Options: optionDiffs(ns, DiffAdded)})
+			d.OptionOrder[name] = ns.optionKeys
+		}
+	}
+	return d
+}
+
+func optionDiffs(s *Section, kind DiffKind) []OptionDiff {
+	var options []OptionDiff
+	for _, opt := range s.orderedOptions() {
+		var od = OptionDiff{Option: opt.key, Kind: kind}
+		if kind == DiffRemoved {
+			od.Old = opt.values
+		} else {
+			od.New = opt.values
+		}
+		options = append(options, od)
+	}
+	return options
+}
+
+func (this *Difference) Empty() bool {
+	return len(this.Sections) == 0
+}
+
+// String 以类似 unified diff 的格式输出, 删除的行以 - 开头, 新增的行以 + 开头
+func (this *Difference) String() string {
+	return this.Format("a", "b")
+}
+
+// Format 与 String 相同, from 和 to 为输出的第一行和第二行中的名称
+func (this *Difference) Format(from, to string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", from, to)
+
+	for _, s := range this.Sections {
+		var prefix = " "
+		switch s.Kind {
+		case DiffAdded:
+			prefix = "+"
+		case DiffRemoved:
+			prefix = "-"
+		}
+		fmt.Fprintf(&b, "%s[%s]\n", prefix, s.Section)
+
+		for _, opt := range s.Options {
+			for _, value := range opt.Old {
+				fmt.Fprintf(&b, "-%s = %s\n", opt.Option, strings.ReplaceAll(value, "\n", "\n-\t"))
+			}
+			for _, value := range opt.New {
+				fmt.Fprintf(&b, "+%s = %s\n", opt.Option, strings.ReplaceAll(value, "\n", "\n+\t"))
+			}
+		}
+	}
+	return b.String()
+}
+
+// Apply 将变化应用到 c 上, c 中的值与变化前的值不一致时返回错误并且不做任何修改;
+// 应用之后 section 和 option 的顺序与 Diff 时的 b 相同
+func (this *Difference) Apply(c *Ini) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := this.check(c); err != nil {
+		return err
+	}
+
+	for _, s := range this.Sections {
+		if s.Kind == DiffRemoved {
+			// default section 也可能被删除, 不能使用 removeSection
+			c.deleteSection(s.Section)
+			continue
+		}
+
+		var section = c.newSection(s.Section)
+		for _, opt := range s.Options {
+			switch opt.Kind {
+			case DiffRemoved:
+				section.RemoveOption(opt.Option)
+			default:
+				section.newOption(opt.Option, "=").replaceValues(opt.New, nil)
+			}
+		}
+		if order, ok := this.OptionOrder[s.Section]; ok {
+			section.reorder(order)
+		}
+	}
+
+	if this.SectionOrder != nil {
+		c.reorder(this.SectionOrder)
+	}
+	return nil
+}
+
+// check 检查 c 中的值是否与变化前的值一致
+func (this *Difference) check(c *Ini) error {
+	for _, s := range this.Sections {
+		var section = c.section(s.Section)
+		if s.Kind == DiffAdded {
+			if section != nil {
+				return fmt.Errorf("ini4go: 补丁与配置不匹配: [%s] 已经存在", s.Section)
+			}
+			continue
+		}
+		if section == nil {
+			return fmt.Errorf("ini4go: 补丁与配置不匹配: [%s] 不存在", s.Section)
+		}
+
+		for _, opt := range s.Options {
+			var current = section.Option(opt.Option)
+			if opt.Kind == DiffAdded {
+				if current != nil {
+					return fmt.Errorf("ini4go: 补丁与配置不匹配: [%s] %s 已经存在", s.Section, opt.Option)
+				}
+				continue
+			}
+			if current == nil || !reflect.DeepEqual(current.rawValues(), opt.Old) {
+				return fmt.Errorf("ini4go: 补丁与配置不匹配: [%s] %s 的值不同", s.Section, opt.Option)
+			}
+		}
+	}
+	return nil
+}
+
+// reorder 按照 order 重新排列 section, 不在 order 中的 section 保持原来的顺序排在最后
+func (this *iniParser) reorder(order []string) {
+	this.sectionKeys = reorderKeys(this.sectionKeys, order)
+	this.modified()
+}
+
+func (this *Section) reorder(order []string) {
+	this.lock()
+	defer this.unlock()
+
+	this.optionKeys = reorderKeys(this.optionKeys, order)
+	this.modified()
+}
+
+func reorderKeys(keys, order []string) []string {
+	var exists = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		exists[key] = true
+	}
+
+	var result = make([]string, 0, len(keys))
+	var added = make(map[string]bool, len(keys))
+	for _, key := range order {
+		if exists[key] && !added[key] {
+			added[key] = true
+			result = append(result, key)
+		}
+	}
+	for _, key := range keys {
+		if !added[key] {
+			result = append(result, key)
+		}
+	}
+	return result
+}
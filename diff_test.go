@@ -0,0 +1,97 @@
+package ini4go
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var newConfig = func(src string) *Ini {
+		var r = New(false)
+		r.LoadString("app.conf", src)
+		return r
+	}
+	var a = newConfig(`[server]
+host = a
+port = 80
+
+[db]
+host = h1
+host = h2
+
+[old]
+k = v
+`)
+	var b = newConfig(`[new]
+k = v
+
+[server]
+debug = true
+host = a
+port = 8080
+
+[db]
+host = h1
+host = h3
+`)
+
+	var d = Diff(a, b)
+	var expected = `--- a.conf
++++ b.conf
+ [server]
+-port = 80
++port = 8080
++debug = true
+ [db]
+-host = h1
+-host = h2
++host = h1
++host = h3
+-[old]
+-k = v
++[new]
++k = v
+`
+	if d.Format("a.conf", "b.conf") != expected {
+		t.Errorf("输出错误:\n%s", d.Format("a.conf", "b.conf"))
+	}
+
+	var data, _ = json.Marshal(d.Sections[0])
+	if string(data) != `{"section":"server","kind":"changed","options":[{"option":"port","kind":"changed","old":["80"],"new":["8080"]},{"option":"debug","kind":"added","new":["true"]}]}` {
+		t.Error("JSON 格式错误", string(data))
+	}
+
+	if err := d.Apply(a); err != nil {
+		t.Fatal(err)
+	}
+	if !Diff(a, b).Empty() {
+		t.Error("应用补丁之后应该与 b 相同", Diff(a, b))
+	}
+	if a.String() != b.String() {
+		t.Errorf("应用补丁之后的顺序应该与 b 相同:\n%s", a)
+	}
+
+	// 再次应用时与变化前的值不一致
+	if err := d.Apply(a); err == nil {
+		t.Error("重复应用补丁应该返回错误")
+	}
+	if fmt.Sprint(a.GetValues("db", "host")) != "[h1 h3]" {
+		t.Error("应用失败时不应该修改配置")
+	}
+
+	// 通过 JSON 传递的补丁, 包括删除 default section
+	a = newConfig("name = app\n\n[s1]\nk1 = v1\nk2 = v2\n")
+	b = newConfig("[s2]\nk = v\n\n[s1]\nk2 = v2\nk1 = v3\n")
+	data, _ = json.Marshal(Diff(a, b))
+	var patch = &Difference{}
+	if err := json.Unmarshal(data, patch); err != nil {
+		t.Fatal(err)
+	}
+	if err := patch.Apply(a); err != nil {
+		t.Fatal(err)
+	}
+	if a.HasSection("default") || a.String() != b.String() {
+		t.Errorf("应用补丁之后应该与 b 相同:\n%s", a)
+	}
+}
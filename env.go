@@ -0,0 +1,121 @@
+package ini4go
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	kEnvSeparator = "__"
+)
+
+type EnvOptions struct {
+	// 环境变量中 section 与 option 之间的分隔符, 默认为 "__"
+	Separator string
+
+	// 默认将 section 和 option 转换为小写, 并且忽略大小写匹配已经存在的 section 和 option
+	CaseSensitive bool
+
+	// 只允许覆盖已经存在的 option, 存在无法对应的环境变量时返回错误并且不修改配置
+	Strict bool
+
+	// 使用的环境变量, 格式同 os.Environ(), 默认为 os.Environ()
+	Environ []string
+}
+
+// ApplyEnv 使用以 prefix 开头的环境变量覆盖配置, 如 APP__DATABASE__HOST 对应 database section 中的 host,
+// APP__NAME 对应 default section 中的 name; prefix 不能为空, 否则所有的环境变量都会写入配置
+func (this *iniParser) ApplyEnv(prefix string) error {
+	var _, err = this.ApplyEnvWithOptions(prefix, EnvOptions{})
+	return err
+}
+
+// ApplyEnvWithOptions 同 ApplyEnv, 返回无法对应的环境变量
+func (this *iniParser) ApplyEnvWithOptions(prefix string, opts EnvOptions) ([]string, error) {
+	if prefix == "" {
+		return nil, errors.New("ini4go: ApplyEnv 的 prefix 不能为空")
+	}
+	if opts.Separator == "" {
+		opts.Separator = kEnvSeparator
+	}
+	if opts.Environ == nil {
+		opts.Environ = os.Environ()
+	}
+	prefix += opts.Separator
+
+	type envValue struct {
+		section string
+		option  string
+		value   string
+	}
+
+	var values []envValue
+	var unmatched []string
+
+	this.RLock()
+	for _, env := range opts.Environ {
+		var i = strings.Index(env, "=")
+		if i <= 0 || !strings.HasPrefix(env[:i], prefix) {
+			continue
+		}
+
+		var name, value = env[:i], env[i+1:]
+		var parts = strings.Split(name[len(prefix):], opts.Separator)
+		if len(parts) == 1 {
+			parts = []string{kDefaultSection, parts[0]}
+		}
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			unmatched = append(unmatched, name)
+			continue
+		}
+
+		var section, option = parts[0], parts[1]
+		if !opts.CaseSensitive {
+			section = this.foldSection(section)
+			option = this.foldOption(section, option)
+		}
+
+		if opts.Strict && !this.hasOption(section, option) {
+			unmatched = append(unmatched, name)
+			continue
+		}
+		values = append(values, envValue{section: section, option: option, value: value})
+	}
+	this.RUnlock()
+
+	if opts.Strict && len(unmatched) > 0 {
+		return unmatched, fmt.Errorf("ini4go: 无法对应的环境变量: %s", strings.Join(unmatched, ", "))
+	}
+
+	for _, v := range values {
+		this.SetValue(v.section, v.option, v.value)
+	}
+	return unmatched, nil
+}
+
+// foldSection 返回与 name 忽略大小写相同的 section, 不存在时返回小写的 name
+func (this *iniParser) foldSection(name string) string {
+	if strings.EqualFold(name, kDefaultSection) {
+		return kDefaultSection
+	}
+	for _, key := range this.sectionKeys {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return strings.ToLower(name)
+}
+
+// foldOption 返回 section 中与 name 忽略大小写相同的 option, 不存在时返回小写的 name
+func (this *iniParser) foldOption(section, name string) string {
+	if s, _ := this.sections.Load(section); s != nil {
+		for _, key := range s.(*Section).OptionKeys() {
+			if strings.EqualFold(key, name) {
+				return key
+			}
+		}
+	}
+	return strings.ToLower(name)
+}
@@ -0,0 +1,54 @@
+package ini4go
+
+import "testing"
+
+func TestApplyEnv(t *testing.T) {
+	var r = New(false)
+	r.LoadString("app.conf", "[Database]\nHost = localhost\nport = 3306\n")
+
+	var environ = []string{
+		"APP__DATABASE__HOST=db.example.com",
+		"APP__CACHE__TTL=60",
+		"APP__NAME=app",
+		"APP__A__B__C=x",
+		"OTHER__DATABASE__PORT=1",
+	}
+
+	var unmatched, err = r.ApplyEnvWithOptions("APP", EnvOptions{Environ: environ})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unmatched) != 1 || unmatched[0] != "APP__A__B__C" {
+		t.Error("APP__A__B__C 应该无法对应", unmatched)
+	}
+	if r.GetValue("Database", "Host") != "db.example.com" {
+		t.Error("Database -> Host 应该被环境变量覆盖")
+	}
+	if r.GetValue("Database", "port") != "3306" {
+		t.Error("其它前缀的环境变量不应该生效")
+	}
+	if r.GetValue("cache", "ttl") != "60" || r.GetValue("default", "name") != "app" {
+		t.Error("不存在的 option 应该被创建")
+	}
+
+	r = New(false)
+	r.LoadString("app.conf", "[database]\nhost = localhost\n")
+	unmatched, err = r.ApplyEnvWithOptions("APP", EnvOptions{Environ: environ, Strict: true})
+	if err == nil || len(unmatched) != 3 {
+		t.Error("严格模式下应该返回无法对应的环境变量", unmatched)
+	}
+	if r.GetValue("database", "host") != "localhost" {
+		t.Error("严格模式下出错时不应该修改配置")
+	}
+
+	r = New(false)
+	r.ApplyEnvWithOptions("app", EnvOptions{Environ: []string{"app.db.Host=h"}, Separator: ".", CaseSensitive: true})
+	if !r.HasOption("db", "Host") {
+		t.Error("区分大小写时应该保留原有的名称")
+	}
+
+	r = New(false)
+	if _, err = r.ApplyEnvWithOptions("", EnvOptions{Environ: environ}); err == nil || len(r.SectionNames()) != 0 {
+		t.Error("prefix 为空时应该返回错误", err)
+	}
+}
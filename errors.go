@@ -0,0 +1,117 @@
+package ini4go
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrSectionNotFound = errors.New("section 不存在")
+	ErrOptionNotFound  = errors.New("option 不存在")
+	ErrInvalidValue    = errors.New("无效的值")
+)
+
+type ParseErrorReason int
+
+const (
+	ReasonInvalidSection ParseErrorReason = iota + 1
+	ReasonMissingKey
+	ReasonDuplicateOption
+	ReasonInvalidValue
+)
+
+func (this ParseErrorReason) String() string {
+	switch this {
+	case ReasonInvalidSection:
+		return "无效的 Section"
+	case ReasonMissingKey:
+		return "缺少 Option 名称"
+	case ReasonDuplicateOption:
+		return "有重复的 Option"
+	case ReasonInvalidValue:
+		return "无效的值"
+	}
+	return fmt.Sprintf("ParseErrorReason(%d)", int(this))
+}
+
+// ParseError 描述配置内容中无法解析的一行, Line 和 Column 均从 1 开始计数
+type ParseError struct {
+	Source string
+	Line   int
+	Column int
+	Text   string
+	Reason ParseErrorReason
+}
+
+func (this *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", this.Source, this.Line, this.Column, this.Reason, this.Text)
+}
+
+type InterpolationErrorReason int
+
+const (
+	InterpolationMissing InterpolationErrorReason = iota + 1
+	InterpolationCycle
+	InterpolationDepth
+)
+
+func (this InterpolationErrorReason) String() string {
+	switch this {
+	case InterpolationMissing:
+		return "引用的 Option 不存在"
+	case InterpolationCycle:
+		return "变量存在循环引用"
+	case InterpolationDepth:
+		return "变量引用的层级过深"
+	}
+	return fmt.Sprintf("InterpolationErrorReason(%d)", int(this))
+}
+
+// InterpolationError 描述变量替换失败的原因, Chain 为依次引用的 option, 格式为 section:key, 最后一个为出错的引用
+type InterpolationError struct {
+	Chain  []string
+	Reason InterpolationErrorReason
+}
+
+func newInterpolationError(reason InterpolationErrorReason, chain []string, ref string) *InterpolationError {
+	var err = &InterpolationError{}
+	err.Chain = append(append([]string{}, chain...), ref)
+	err.Reason = reason
+	return err
+}
+
+func (this *InterpolationError) Error() string {
+	return fmt.Sprintf("%s: %s", this.Reason, strings.Join(this.Chain, " -> "))
+}
+
+// ValueError 描述无法解析为指定类型的值, 可以通过 errors.Is(err, ErrInvalidValue) 判断
+type ValueError struct {
+	Section string
+	Option  string
+	Value   string
+	Err     error
+}
+
+func newValueError(opt *Option, err error) *ValueError {
+	var vErr = &ValueError{}
+	if opt.section != nil {
+		vErr.Section = opt.section.name
+	}
+	vErr.Option = opt.key
+	vErr.Value, _ = opt.rawValue(0)
+	vErr.Err = err
+	return vErr
+}
+
+func (this *ValueError) Error() string {
+	return fmt.Sprintf("ini4go: [%s] %s: %s %q: %v", this.Section, this.Option, ErrInvalidValue, this.Value, this.Err)
+}
+
+func (this *ValueError) Unwrap() error {
+	return this.Err
+}
+
+func (this *ValueError) Is(target error) bool {
+	return target == ErrInvalidValue
+}
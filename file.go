@@ -0,0 +1,117 @@
+package ini4go
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	kFileMode     = 0644
+	kBackupSuffix = ".bak"
+)
+
+type WriteOptions struct {
+	// 文件的权限, 为 0 时文件已经存在则保持原有的权限, 否则使用 0644
+	Perm fs.FileMode
+
+	// 写入之前将原来的文件保存为 file.bak, 只保留上一个版本
+	Backup bool
+}
+
+// WriteToFile 将配置写入 file, 见 WriteToFileWithOptions
+func (this *iniParser) WriteToFile(file string) error {
+	return this.WriteToFileWithOptions(file, WriteOptions{})
+}
+
+// WriteToFileWithOptions 先将配置写入同一目录下的临时文件并调用 fsync, 再重命名为 file,
+// 写入过程中出错或者崩溃时 file 保持原来的内容; 文件已经存在时保持原有的权限和所有者, file 为符号链接时写入其指向的文件
+func (this *iniParser) WriteToFileWithOptions(file string, opts WriteOptions) (err error) {
+	if target, err := filepath.EvalSymlinks(file); err == nil {
+		file = target
+	}
+
+	var fileInfo, statErr = os.Stat(file)
+	var perm = opts.Perm
+	if perm == 0 {
+		perm = kFileMode
+		if statErr == nil {
+			perm = fileInfo.Mode().Perm()
+		}
+	}
+
+	var dir = filepath.Dir(file)
+	var tmp *os.File
+	if tmp, err = os.CreateTemp(dir, "."+filepath.Base(file)+".*.tmp"); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = this.WriteTo(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if statErr == nil {
+		if err = chown(tmp, fileInfo); err != nil {
+			return err
+		}
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if opts.Backup && statErr == nil {
+		if err = backupFile(file, file+kBackupSuffix); err != nil {
+			return err
+		}
+	}
+
+	if err = os.Rename(tmp.Name(), file); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// backupFile 将 file 保存为 backup, 优先使用硬链接, 不支持时复制文件内容
+func backupFile(file, backup string) error {
+	if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(file, backup); err == nil {
+		return nil
+	}
+
+	var src, err = os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	var fileInfo os.FileInfo
+	if fileInfo, err = src.Stat(); err != nil {
+		return err
+	}
+
+	var dst *os.File
+	if dst, err = os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fileInfo.Mode().Perm()); err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err == nil {
+		err = dst.Sync()
+	}
+	if cErr := dst.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package ini4go
+
+import "os"
+
+func chown(f *os.File, fileInfo os.FileInfo) error {
+	return nil
+}
+
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,88 @@
+package ini4go
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteToFile(t *testing.T) {
+	var dir = t.TempDir()
+	var file = filepath.Join(dir, "app.conf")
+
+	var r = New(false)
+	r.SetValue("s1", "k1", "v1")
+	if err := r.WriteToFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	var fileInfo, err = os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && fileInfo.Mode().Perm() != kFileMode {
+		t.Errorf("新文件的权限应该为 %v, 实际为 %v", os.FileMode(kFileMode), fileInfo.Mode().Perm())
+	}
+
+	// 保持原有的权限, 并且保存上一个版本
+	if runtime.GOOS != "windows" {
+		os.Chmod(file, 0600)
+	}
+	r.SetValue("s1", "k1", "v2")
+	if err = r.WriteToFileWithOptions(file, WriteOptions{Backup: true}); err != nil {
+		t.Fatal(err)
+	}
+	if fileInfo, _ = os.Stat(file); runtime.GOOS != "windows" && fileInfo.Mode().Perm() != 0600 {
+		t.Error("应该保持原有的权限", fileInfo.Mode().Perm())
+	}
+
+	var check = func(file, value string) {
+		var data, err = os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var c = New(false)
+		c.LoadBytes(file, data)
+		if c.GetValue("s1", "k1") != value {
+			t.Errorf("%s 中 s1 -> k1 应该为 %s, 实际为 %s", file, value, c.GetValue("s1", "k1"))
+		}
+	}
+	check(file, "v2")
+	check(file+kBackupSuffix, "v1")
+
+	// 指定权限
+	if err = r.WriteToFileWithOptions(file, WriteOptions{Perm: 0640}); err != nil {
+		t.Fatal(err)
+	}
+	if fileInfo, _ = os.Stat(file); runtime.GOOS != "windows" && fileInfo.Mode().Perm() != 0640 {
+		t.Error("权限应该为 0640", fileInfo.Mode().Perm())
+	}
+
+	var entries, _ = os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Error("不应该留下临时文件", entries)
+	}
+}
+
+func TestWriteToFileSymlink(t *testing.T) {
+	var dir = t.TempDir()
+	var target = filepath.Join(dir, "target.conf")
+	var link = filepath.Join(dir, "link.conf")
+	os.WriteFile(target, []byte("[s1]\nk1 = v1\n"), 0600)
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip(err)
+	}
+
+	var r = New(false)
+	r.SetValue("s1", "k1", "v2")
+	if err := r.WriteToFile(link); err != nil {
+		t.Fatal(err)
+	}
+	if fileInfo, _ := os.Lstat(link); fileInfo.Mode()&os.ModeSymlink == 0 {
+		t.Error("符号链接不应该被替换")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "[s1]\nk1 = v2\n" {
+		t.Error("应该写入符号链接指向的文件", string(data))
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !windows && !plan9
+
+package ini4go
+
+import (
+	"os"
+	"syscall"
+)
+
+// chown 将 f 的所有者设置为与 fileInfo 相同, 没有权限修改时保持当前用户
+func chown(f *os.File, fileInfo os.FileInfo) error {
+	var stat, ok = fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if err := f.Chown(int(stat.Uid), int(stat.Gid)); err != nil && !os.IsPermission(err) {
+		return err
+	}
+	return nil
+}
+
+// syncDir 将目录中的重命名操作写入磁盘
+func syncDir(dir string) error {
+	var d, err = os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,144 @@
+package ini4go
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// FlagName 返回 option 对应的命令行参数名称, default section 中的 option 使用 option 作为名称, 其它为 section.option
+func FlagName(section, option string) string {
+	if section == kDefaultSection {
+		return option
+	}
+	return section + "." + option
+}
+
+// optionFlag 是写入到 Ini 中的 flag.Value
+type optionFlag struct {
+	parser  *iniParser
+	section string
+	option  string
+}
+
+func (this *optionFlag) String() string {
+	if this == nil || this.parser == nil {
+		return ""
+	}
+
+	this.parser.RLock()
+	defer this.parser.RUnlock()
+
+	var values = this.parser.values(this.section, this.option)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (this *optionFlag) Set(value string) error {
+	this.parser.SetValue(this.section, this.option, value)
+	return nil
+}
+
+// IsBoolFlag 值为 true 或者 false 的 option 可以只写参数名称, 如 -debug
+func (this *optionFlag) IsBoolFlag() bool {
+	var value = strings.ToLower(this.String())
+	return value == "true" || value == "false"
+}
+
+// FlagValue 返回 section 中 option 对应的 flag.Value, 通过命令行设置的值会写入到配置中
+func (this *iniParser) FlagValue(section, option string) flag.Value {
+	return &optionFlag{parser: this, section: section, option: option}
+}
+
+// RegisterFlags 将所有的 option 注册到 fs 中, 参数名称见 FlagName, option 的注释作为参数的说明,
+// fs 中已经存在的参数会被忽略
+func (this *iniParser) RegisterFlags(fs *flag.FlagSet) {
+	for _, section := range this.SectionNames() {
+		for _, opt := range this.optionsOf(section) {
+			var name = FlagName(section, opt.Key())
+			if fs.Lookup(name) != nil {
+				continue
+			}
+			fs.Var(this.FlagValue(section, opt.Key()), name, opt.Comment())
+		}
+	}
+}
+
+// SetFlagDefaults 使用配置中的值作为 fs 中已经定义的参数的默认值, 之后解析的命令行参数会覆盖这些值
+func (this *iniParser) SetFlagDefaults(fs *flag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := f.Value.(*optionFlag); ok || err != nil {
+			return
+		}
+
+		var section, option, ok = this.flagTarget(f.Name)
+		if !ok {
+			return
+		}
+
+		var value = this.GetValue(section, option)
+		if setErr := f.Value.Set(value); setErr != nil {
+			err = fmt.Errorf("ini4go: 参数 -%s 的默认值 %q 无效: %v", f.Name, value, setErr)
+			return
+		}
+		f.DefValue = value
+	})
+	return err
+}
+
+// ApplyFlags 将 fs 中通过命令行设置的参数写入到配置中
+func (this *iniParser) ApplyFlags(fs *flag.FlagSet) {
+	fs.Visit(func(f *flag.Flag) {
+		if _, ok := f.Value.(*optionFlag); ok {
+			return
+		}
+
+		var section, option = flagSectionOption(f.Name)
+		if s, o, ok := this.flagTarget(f.Name); ok {
+			section, option = s, o
+		}
+		this.SetValue(section, option, f.Value.String())
+	})
+}
+
+// flagTarget 返回参数名称对应的已经存在的 option
+func (this *iniParser) flagTarget(name string) (string, string, bool) {
+	this.RLock()
+	defer this.RUnlock()
+
+	var section, option string
+	for _, key := range this.sectionKeys {
+		if strings.HasPrefix(name, key+".") && len(key) > len(section) {
+			section, option = key, name[len(key)+1:]
+		}
+	}
+	if section != "" && this.hasOption(section, option) {
+		return section, option, true
+	}
+	if this.hasOption(kDefaultSection, name) {
+		return kDefaultSection, name, true
+	}
+	return "", "", false
+}
+
+func flagSectionOption(name string) (string, string) {
+	if i := strings.Index(name, "."); i > 0 {
+		return name[:i], name[i+1:]
+	}
+	return kDefaultSection, name
+}
+
+func (this *iniParser) optionsOf(section string) []*Option {
+	this.RLock()
+	defer this.RUnlock()
+
+	var s, _ = this.sections.Load(section)
+	if s == nil {
+		return nil
+	}
+
+	return s.(*Section).orderedOptions()
+}
@@ -0,0 +1,55 @@
+package ini4go
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	var r = New(false)
+	r.LoadString("app.conf", "debug = false\n\n[database]\n# 数据库地址\nhost = localhost\nport = 3306\n")
+
+	var fs = flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	r.RegisterFlags(fs)
+
+	if fs.Lookup("database.host") == nil || fs.Lookup("database.host").Usage != "数据库地址" {
+		t.Fatal("应该注册 database.host 参数")
+	}
+	if err := fs.Parse([]string{"-debug", "-database.host=db.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if r.GetValue("database", "host") != "db.example.com" || r.GetValue("default", "debug") != "true" {
+		t.Error("命令行参数应该写入到配置中")
+	}
+	if r.GetValue("database", "port") != "3306" {
+		t.Error("没有设置的参数应该保留配置中的值")
+	}
+}
+
+func TestSetFlagDefaults(t *testing.T) {
+	var r = New(false)
+	r.LoadString("app.conf", "[database]\nport = 3306\nhost = localhost\n")
+
+	var fs = flag.NewFlagSet("app", flag.ContinueOnError)
+	var port = fs.Int("database.port", 0, "")
+	var host = fs.String("database.host", "", "")
+	var name = fs.String("name", "app", "")
+
+	if err := r.SetFlagDefaults(fs); err != nil {
+		t.Fatal(err)
+	}
+	if *port != 3306 || *host != "localhost" || *name != "app" {
+		t.Error("应该使用配置中的值作为默认值")
+	}
+
+	fs.Parse([]string{"-database.host", "db.example.com", "-name", "test"})
+	r.ApplyFlags(fs)
+	if r.GetValue("database", "host") != "db.example.com" || r.GetValue("default", "name") != "test" {
+		t.Error("命令行参数应该覆盖配置中的值")
+	}
+	if r.GetValue("database", "port") != "3306" {
+		t.Error("没有设置的参数应该保留配置中的值")
+	}
+}
@@ -0,0 +1,133 @@
+package ini4go
+
+import (
+	"fmt"
+	"time"
+)
+
+// lookupOption 查找 option, 不会创建新的 section 和 option,
+// 不存在时返回的错误可以通过 errors.Is 与 ErrSectionNotFound、ErrOptionNotFound 比较
+func (this *iniParser) lookupOption(section, option string) (*Option, error) {
+	var s, _ = this.sections.Load(section)
+	if s == nil {
+		return nil, fmt.Errorf("ini4go: [%s]: %w", section, ErrSectionNotFound)
+	}
+
+	var opt, _ = s.(*Section).options.Load(option)
+	if opt == nil {
+		return nil, fmt.Errorf("ini4go: [%s] %s: %w", section, option, ErrOptionNotFound)
+	}
+	return opt.(*Option), nil
+}
+
+func (this *iniParser) getOption(section, option string) (*Option, error) {
+	this.RLock()
+	defer this.RUnlock()
+
+	return this.lookupOption(section, option)
+}
+
+// Value 返回替换变量之后的值, 与 GetValue 不同, option 不存在时返回错误
+func (this *iniParser) Value(section, option string) (string, error) {
+	var opt, err = this.getOption(section, option)
+	if err != nil {
+		return "", err
+	}
+
+	var v string
+	if v, err = opt.Resolve(); err != nil {
+		return "", newValueError(opt, err)
+	}
+	return v, nil
+}
+
+func (this *iniParser) Int(section, option string) (int, error) {
+	var opt, err = this.getOption(section, option)
+	if err != nil {
+		return 0, err
+	}
+
+	var v int
+	if v, err = opt.Int(); err != nil {
+		return 0, newValueError(opt, err)
+	}
+	return v, nil
+}
+
+func (this *iniParser) Int64(section, option string) (int64, error) {
+	var opt, err = this.getOption(section, option)
+	if err != nil {
+		return 0, err
+	}
+
+	var v int64
+	if v, err = opt.Int64(); err != nil {
+		return 0, newValueError(opt, err)
+	}
+	return v, nil
+}
+
+func (this *iniParser) Float32(section, option string) (float32, error) {
+	var opt, err = this.getOption(section, option)
+	if err != nil {
+		return 0, err
+	}
+
+	var v float32
+	if v, err = opt.Float32(); err != nil {
+		return 0, newValueError(opt, err)
+	}
+	return v, nil
+}
+
+func (this *iniParser) Float64(section, option string) (float64, error) {
+	var opt, err = this.getOption(section, option)
+	if err != nil {
+		return 0, err
+	}
+
+	var v float64
+	if v, err = opt.Float64(); err != nil {
+		return 0, newValueError(opt, err)
+	}
+	return v, nil
+}
+
+func (this *iniParser) Bool(section, option string) (bool, error) {
+	var opt, err = this.getOption(section, option)
+	if err != nil {
+		return false, err
+	}
+
+	var v bool
+	if v, err = opt.Bool(); err != nil {
+		return false, newValueError(opt, err)
+	}
+	return v, nil
+}
+
+func (this *iniParser) Time(section, option string) (time.Time, error) {
+	var opt, err = this.getOption(section, option)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var v time.Time
+	if v, err = opt.Time(); err != nil {
+		return time.Time{}, newValueError(opt, err)
+	}
+	return v, nil
+}
+
+func (this *iniParser) TimeWithLayout(section, option, layout string) (time.Time, error) {
+	var opt, err = this.getOption(section, option)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var v time.Time
+	if v, err = opt.TimeWithLayout(layout); err != nil {
+		return time.Time{}, newValueError(opt, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,168 @@
+package ini4go
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+type Interpolation int
+
+const (
+	// InterpolationBasic 使用 %(key)s 引用当前 section 中的值, %% 表示 %, 默认使用该方式
+	InterpolationBasic Interpolation = iota
+
+	// InterpolationExtended 使用 ${key} 引用当前 section 中的值, ${section:key} 引用其它 section 中的值,
+	// ${env:NAME} 引用环境变量, ${NAME:-fallback} 引用环境变量并在其不存在时使用 fallback, $$ 表示 $
+	InterpolationExtended
+
+	// InterpolationNone 不处理变量
+	InterpolationNone
+)
+
+const (
+	kEnvSection = "env"
+
+	// 变量引用的最大深度
+	kMaxInterpolationDepth = 10
+)
+
+var basicRegexp = regexp.MustCompile(`%%|%\((\S[^()]*)\)s`)
+
+var extendedRegexp = regexp.MustCompile(`\$\$|\$\{([^{}]+)\}`)
+
+// SetInterpolation 设置变量替换的方式, 引用的 option 在指定的 section 中不存在时会查找 default section
+func (this *iniParser) SetInterpolation(mode Interpolation) {
+	this.interpolation = mode
+	this.modified()
+}
+
+// parseValue 替换 raw 中的变量, chain 为当前正在替换的 option 链, 用于检测循环引用
+func (this *Option) parseValue(raw string, chain []string) (string, error) {
+	var mode = InterpolationBasic
+	if this.section != nil && this.section.parser != nil {
+		mode = this.section.parser.interpolation
+	}
+
+	var err error
+	var result string
+	switch mode {
+	case InterpolationNone:
+		return raw, nil
+	case InterpolationExtended:
+		result = extendedRegexp.ReplaceAllStringFunc(raw, func(src string) string {
+			if src == "$$" {
+				return "$"
+			}
+			if err != nil {
+				return ""
+			}
+			var value string
+			value, err = this.expandExtended(src[2:len(src)-1], chain)
+			return value
+		})
+	default:
+		result = basicRegexp.ReplaceAllStringFunc(raw, func(src string) string {
+			if src == "%%" {
+				return "%"
+			}
+			if err != nil {
+				return ""
+			}
+			var value string
+			value, err = this.lookupValue("", src[2:len(src)-2], chain)
+			return value
+		})
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+func (this *Option) expandExtended(name string, chain []string) (string, error) {
+	if i := strings.Index(name, ":-"); i >= 0 {
+		if value, ok := os.LookupEnv(name[:i]); ok {
+			return value, nil
+		}
+		return name[i+2:], nil
+	}
+
+	if i := strings.Index(name, ":"); i >= 0 {
+		var section, key = name[:i], name[i+1:]
+		if section == kEnvSection {
+			return os.Getenv(key), nil
+		}
+		return this.lookupValue(section, key, chain)
+	}
+	return this.lookupValue("", name, chain)
+}
+
+// lookupValue 返回 section 中 key 替换变量之后的值, section 为空时表示当前 section
+func (this *Option) lookupValue(section, key string, chain []string) (string, error) {
+	var opt = this.lookup(section, key)
+	if opt == nil {
+		if section == "" && this.section != nil {
+			section = this.section.name
+		}
+		return "", newInterpolationError(InterpolationMissing, chain, section+":"+key)
+	}
+
+	var id = opt.id()
+	for _, c := range chain {
+		if c == id {
+			return "", newInterpolationError(InterpolationCycle, chain, id)
+		}
+	}
+	if len(chain) > kMaxInterpolationDepth {
+		return "", newInterpolationError(InterpolationDepth, chain, id)
+	}
+
+	var raw, ok = opt.rawValue(0)
+	if !ok {
+		return "", nil
+	}
+	return opt.parseValue(raw, append(chain[:len(chain):len(chain)], id))
+}
+
+// id 返回 option 在变量引用链中的名称
+func (this *Option) id() string {
+	if this.section == nil {
+		return this.key
+	}
+	return this.section.name + ":" + this.key
+}
+
+// lookup 查找 section 中的 option, 不存在时查找 default section, 不会创建新的 section 和 option
+func (this *Option) lookup(section, key string) *Option {
+	var s = this.section
+	if s == nil {
+		return nil
+	}
+
+	var parser = s.parser
+	if section != "" && section != s.name {
+		s = nil
+		if parser != nil {
+			if v, _ := parser.sections.Load(section); v != nil {
+				s = v.(*Section)
+			}
+		}
+	}
+
+	if s != nil {
+		if opt, _ := s.options.Load(key); opt != nil {
+			return opt.(*Option)
+		}
+	}
+
+	if parser != nil {
+		if v, _ := parser.sections.Load(kDefaultSection); v != nil {
+			if opt, _ := v.(*Section).options.Load(key); opt != nil {
+				return opt.(*Option)
+			}
+		}
+	}
+	return nil
+}
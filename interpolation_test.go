@@ -0,0 +1,117 @@
+package ini4go
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInterpolation(t *testing.T) {
+	var src = `[default]
+home = /opt/app
+
+[s1]
+data = %(home)s/data
+percent = 100%%
+short = %(a)s
+a = x
+`
+	var r = New(false)
+	r.LoadString("basic.conf", src)
+
+	if r.GetValue("s1", "data") != "/opt/app/data" {
+		t.Error("应该从 default section 中查找 home", r.GetValue("s1", "data"))
+	}
+	if r.GetValue("s1", "percent") != "100%" {
+		t.Error("%% 应该替换为 %", r.GetValue("s1", "percent"))
+	}
+	if r.GetValue("s1", "short") != "x" {
+		t.Error("s1 -> short 应该为 x", r.GetValue("s1", "short"))
+	}
+
+	os.Setenv("INI4GO_TEST_HOST", "example.com")
+	defer os.Unsetenv("INI4GO_TEST_HOST")
+
+	src = `[default]
+home = /opt/app
+
+[db]
+host = ${env:INI4GO_TEST_HOST}
+port = ${INI4GO_TEST_PORT:-3306}
+user = ${INI4GO_TEST_HOST:-nobody}
+
+[s1]
+dsn = ${db:host}:${db:port}
+log = ${home}/log
+price = $$5
+`
+	r = New(false)
+	r.SetInterpolation(InterpolationExtended)
+	r.LoadString("extended.conf", src)
+
+	if r.GetValue("s1", "dsn") != "example.com:3306" {
+		t.Error("s1 -> dsn 应该为 example.com:3306", r.GetValue("s1", "dsn"))
+	}
+	if r.GetValue("db", "user") != "example.com" {
+		t.Error("db -> user 应该使用环境变量", r.GetValue("db", "user"))
+	}
+	if r.GetValue("s1", "log") != "/opt/app/log" {
+		t.Error("s1 -> log 应该为 /opt/app/log", r.GetValue("s1", "log"))
+	}
+	if r.GetValue("s1", "price") != "$5" {
+		t.Error("$$ 应该替换为 $", r.GetValue("s1", "price"))
+	}
+
+	r.SetInterpolation(InterpolationNone)
+	if r.GetValue("s1", "price") != "$$5" {
+		t.Error("InterpolationNone 不应该处理变量")
+	}
+}
+
+func TestInterpolationError(t *testing.T) {
+	var src = `[s1]
+a = %(b)s
+b = %(c)s
+c = %(a)s
+d = %(missing)s
+n = %(a)s
+`
+	var r = New(false)
+	r.LoadString("cycle.conf", src)
+
+	var _, err = r.MustOption("s1", "a").Resolve()
+	var iErr *InterpolationError
+	if !errors.As(err, &iErr) || iErr.Reason != InterpolationCycle {
+		t.Fatal("应该返回循环引用的 InterpolationError", err)
+	}
+	if strings.Join(iErr.Chain, " -> ") != "s1:a -> s1:b -> s1:c -> s1:a" {
+		t.Error("InterpolationError 的引用链不正确", iErr.Chain)
+	}
+
+	if r.GetValue("s1", "a") != "%(b)s" {
+		t.Error("变量替换失败时应该返回原始的值", r.GetValue("s1", "a"))
+	}
+	if r.MustInt("s1", "n", 7) != 7 {
+		t.Error("变量替换失败时应该返回默认值")
+	}
+
+	_, err = r.MustOption("s1", "d").Resolve()
+	if !errors.As(err, &iErr) || iErr.Reason != InterpolationMissing {
+		t.Error("应该返回引用不存在的 InterpolationError", err)
+	}
+	if r.HasOption("s1", "missing") {
+		t.Error("变量替换不应该创建新的 option")
+	}
+
+	r = New(false)
+	for i := 0; i < 20; i++ {
+		r.SetValue("s1", fmt.Sprintf("k%d", i), fmt.Sprintf("%%(k%d)s", i+1))
+	}
+	r.SetValue("s1", "k20", "end")
+	_, err = r.MustOption("s1", "k0").Resolve()
+	if !errors.As(err, &iErr) || iErr.Reason != InterpolationDepth {
+		t.Error("应该返回引用层级过深的 InterpolationError", err)
+	}
+}
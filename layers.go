@@ -0,0 +1,206 @@
+package ini4go
+
+import "sync"
+
+// Layer 是 Layers 中的一层配置
+type Layer struct {
+	Name string
+	Ini  *Ini
+}
+
+// Layers 按照优先级保存多层配置, 如默认值、系统配置、用户配置、环境变量和运行时的修改,
+// 每一层都是独立的 Ini, 查找时从优先级最高的一层开始, 返回第一个包含该 option 的层中的值
+type Layers struct {
+	mutex  sync.RWMutex
+	layers []*Layer // 按优先级从低到高排列
+}
+
+func NewLayers() *Layers {
+	return &Layers{}
+}
+
+// Push 添加一层优先级最高的配置, ini 为 nil 时创建一个新的 Ini, 已经存在同名的层时替换该层的配置并保持其优先级不变
+func (this *Layers) Push(name string, ini *Ini) *Ini {
+	if ini == nil {
+		ini = New(true)
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	for _, layer := range this.layers {
+		if layer.Name == name {
+			layer.Ini = ini
+			return ini
+		}
+	}
+	this.layers = append(this.layers, &Layer{Name: name, Ini: ini})
+	return ini
+}
+
+// Layer 返回名称为 name 的层, 不存在时返回 nil
+func (this *Layers) Layer(name string) *Ini {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	for _, layer := range this.layers {
+		if layer.Name == name {
+			return layer.Ini
+		}
+	}
+	return nil
+}
+
+func (this *Layers) Remove(name string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	for i, layer := range this.layers {
+		if layer.Name == name {
+			this.layers = append(this.layers[:i], this.layers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Layers 返回所有的层, 按优先级从低到高排列
+func (this *Layers) Layers() []Layer {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	var layers = make([]Layer, 0, len(this.layers))
+	for _, layer := range this.layers {
+		layers = append(layers, *layer)
+	}
+	return layers
+}
+
+// find 从优先级最高的一层开始查找 option
+func (this *Layers) find(section, option string) (*Option, string) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	for i := len(this.layers) - 1; i >= 0; i-- {
+		var layer = this.layers[i]
+		if opt := layer.Ini.Option(section, option); opt != nil {
+			return opt, layer.Name
+		}
+	}
+	return nil, ""
+}
+
+// Option 返回优先级最高的层中的 option, 不存在时返回 nil
+func (this *Layers) Option(section, option string) *Option {
+	var opt, _ = this.find(section, option)
+	return opt
+}
+
+// LayerOf 返回提供该 option 的层的名称, 不存在时返回空字符串
+func (this *Layers) LayerOf(section, option string) string {
+	var _, name = this.find(section, option)
+	return name
+}
+
+func (this *Layers) Lookup(section, option string) (string, bool) {
+	var opt, _ = this.find(section, option)
+	if opt == nil {
+		return "", false
+	}
+	return opt.Value(), true
+}
+
+func (this *Layers) HasSection(section string) bool {
+	for _, layer := range this.Layers() {
+		if layer.Ini.HasSection(section) {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *Layers) HasOption(section, option string) bool {
+	var opt, _ = this.find(section, option)
+	return opt != nil
+}
+
+// SectionNames 返回所有层中的 section, 按第一次出现的顺序排列
+func (this *Layers) SectionNames() []string {
+	var names []string
+	var exists = make(map[string]bool)
+	for _, layer := range this.Layers() {
+		for _, name := range layer.Ini.SectionNames() {
+			if !exists[name] {
+				exists[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Options 返回所有层中 section 的 option, 按第一次出现的顺序排列
+func (this *Layers) Options(section string) []string {
+	var keys []string
+	var exists = make(map[string]bool)
+	for _, layer := range this.Layers() {
+		for _, key := range layer.Ini.Options(section) {
+			if !exists[key] {
+				exists[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+func (this *Layers) GetValue(section, option string) string {
+	return this.MustValue(section, option, "")
+}
+
+func (this *Layers) GetValues(section, option string) []string {
+	var opt, _ = this.find(section, option)
+	if opt == nil {
+		return nil
+	}
+	return opt.Values()
+}
+
+func (this *Layers) MustValue(section, option, defaultValue string) string {
+	var opt, _ = this.find(section, option)
+	if opt == nil {
+		return defaultValue
+	}
+	return opt.MustString(defaultValue)
+}
+
+func (this *Layers) MustInt(section, option string, defaultValue int) int {
+	var opt, _ = this.find(section, option)
+	if opt == nil {
+		return defaultValue
+	}
+	return opt.MustInt(defaultValue)
+}
+
+func (this *Layers) MustInt64(section, option string, defaultValue int64) int64 {
+	var opt, _ = this.find(section, option)
+	if opt == nil {
+		return defaultValue
+	}
+	return opt.MustInt64(defaultValue)
+}
+
+func (this *Layers) MustFloat64(section, option string, defaultValue float64) float64 {
+	var opt, _ = this.find(section, option)
+	if opt == nil {
+		return defaultValue
+	}
+	return opt.MustFloat64(defaultValue)
+}
+
+func (this *Layers) MustBool(section, option string, defaultValue bool) bool {
+	var opt, _ = this.find(section, option)
+	if opt == nil {
+		return defaultValue
+	}
+	return opt.MustBool(defaultValue)
+}
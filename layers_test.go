@@ -0,0 +1,50 @@
+package ini4go
+
+import "testing"
+
+func TestLayers(t *testing.T) {
+	var defaults = New(true)
+	defaults.SetValue("server", "host", "0.0.0.0")
+	defaults.SetValue("server", "port", "80")
+	defaults.SetValue("log", "level", "info")
+
+	var user = New(true)
+	user.LoadString("user.conf", "[server]\nport = 8080\n")
+
+	var layers = NewLayers()
+	layers.Push("defaults", defaults)
+	layers.Push("user", user)
+	var runtime = layers.Push("runtime", nil)
+
+	if layers.MustInt("server", "port", 0) != 8080 || layers.LayerOf("server", "port") != "user" {
+		t.Error("server -> port 应该由 user 提供")
+	}
+	if layers.GetValue("server", "host") != "0.0.0.0" || layers.LayerOf("server", "host") != "defaults" {
+		t.Error("server -> host 应该由 defaults 提供")
+	}
+
+	runtime.SetValue("server", "port", "9090")
+	if layers.GetValue("server", "port") != "9090" || layers.LayerOf("server", "port") != "runtime" {
+		t.Error("server -> port 应该由 runtime 提供")
+	}
+
+	if _, ok := layers.Lookup("server", "missing"); ok || layers.LayerOf("server", "missing") != "" {
+		t.Error("不存在的 option")
+	}
+	if layers.MustValue("server", "missing", "default") != "default" {
+		t.Error("不存在的 option 应该返回默认值")
+	}
+
+	var names = layers.SectionNames()
+	if len(names) != 2 || names[0] != "server" || names[1] != "log" {
+		t.Error("SectionNames 应该为 server、log", names)
+	}
+	if len(layers.Options("server")) != 2 {
+		t.Error("server 中应该有两个 option", layers.Options("server"))
+	}
+
+	layers.Remove("runtime")
+	if layers.GetValue("server", "port") != "8080" {
+		t.Error("删除 runtime 之后 server -> port 应该为 8080")
+	}
+}
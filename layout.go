@@ -0,0 +1,316 @@
+package ini4go
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sectionLayout 记录源文件中从 section 行开始的一段原始文本, 仅在开启 preserveFormat 之后加载时生成,
+// 同一个 section 出现多次时每次对应一段
+type sectionLayout struct {
+	section  *Section // 文件末尾的空行和注释单独作为一段, 此时为 nil
+	lead     []string // section 行之前的空行和注释
+	header   string   // 原始的 section 行, 隐式的 default section 为空
+	comments int      // 加载时 section 的注释数量, 之后添加的注释写在第一段的 section 行之前
+	lines    []*lineLayout
+}
+
+// lineLayout 对应源文件中 option 的一个值
+type lineLayout struct {
+	before   []string // 该行之前的空行和注释
+	option   *Option
+	index    int
+	raw      string
+	prefix   string // 值之前的原始文本, 包括缩进、key 和分隔符
+	value    string // 加载时的值, 用于判断是否被修改
+	suffix   string
+	eol      string
+	indent   string // 多行值中后续行的缩进
+	comment  string // 加载时的行内注释
+	comments int    // 加载时 option 的注释数量, 之后添加的注释写在第一个值之前
+}
+
+const (
+	kIndent = "\t"
+)
+
+type iniWriter struct {
+	*bufio.Writer
+	counter        *countWriter
+	eol            bool
+	quote          bool
+	multiline      bool
+	commentMarker  string
+	commentMarkers []string
+	newline        string // 新写入的行使用的换行符, 为空时使用 "\n"
+	err            error  // 值无法原样写入时的错误
+}
+
+func newIniWriter(w io.Writer) *iniWriter {
+	var writer = &iniWriter{}
+	writer.counter = &countWriter{w: w}
+	writer.Writer = bufio.NewWriter(writer.counter)
+	writer.eol = true
+	return writer
+}
+
+// written 返回已经写入底层 io.Writer 的字节数, 需要先调用 Flush
+func (this *iniWriter) written() int64 {
+	return this.counter.n
+}
+
+// countWriter 记录写入 w 的字节数
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (this *countWriter) Write(p []byte) (int, error) {
+	var n, err = this.w.Write(p)
+	this.n += int64(n)
+	return n, err
+}
+
+func (this *iniWriter) WriteString(s string) (int, error) {
+	if len(s) > 0 {
+		this.eol = s[len(s)-1] == '\n'
+	}
+	return this.Writer.WriteString(s)
+}
+
+// encodeValue 返回值在文件中的写法, 开启引号时需要的值加上引号, 否则多行的值写为缩进的多行;
+// 没有开启引号时, 值中的行内注释符号、多行值中以注释符号开头或者以反斜杠结尾的行会使重新读取的值不同, 此时记录错误
+func (this *iniWriter) encodeValue(value, indent, eol string) string {
+	if this.quote && needsQuote(value, this.commentMarkers) {
+		return quoteValue(value)
+	}
+	if i, marker := indexInlineComment(value, true, this.commentMarkers); i >= 0 && this.err == nil {
+		this.err = fmt.Errorf("ini4go: 值 %q 中包含行内注释符号 %q, 需要开启 SetQuoteValues 才能写入", value, marker)
+	}
+	if this.multiline && this.err == nil {
+		for i, line := range strings.Split(value, "\n") {
+			if isContinued(line) {
+				this.err = fmt.Errorf("ini4go: 值 %q 中有以反斜杠结尾的行, 需要开启 SetQuoteValues 才能写入", value)
+				break
+			}
+			if line = strings.TrimSpace(line); i > 0 && (strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";")) {
+				this.err = fmt.Errorf("ini4go: 值 %q 中有以注释符号开头的行, 需要开启 SetQuoteValues 才能写入", value)
+				break
+			}
+		}
+	}
+	if strings.Contains(value, "\n") {
+		if indent == "" {
+			indent = kIndent
+		}
+		value = strings.Replace(value, "\n", eol+indent, -1)
+	}
+	return value
+}
+
+// encodeComment 返回行内注释在文件中的写法, 没有设置行内注释符号时不写入
+func (this *iniWriter) encodeComment(comment string) string {
+	if comment == "" || this.commentMarker == "" {
+		return ""
+	}
+	return " " + this.commentMarker + " " + comment
+}
+
+// lineEOL 返回 eol, 为空时返回新写入的行使用的换行符
+func (this *iniWriter) lineEOL(eol string) string {
+	if eol != "" {
+		return eol
+	}
+	if this.newline != "" {
+		return this.newline
+	}
+	return "\n"
+}
+
+// writeText 写入生成的文本, 其中的换行符替换为 newline
+func (this *iniWriter) writeText(s string) {
+	if this.newline != "" && this.newline != "\n" {
+		s = strings.Replace(s, "\n", this.newline, -1)
+	}
+	this.WriteString(s)
+}
+
+func (this *iniWriter) ensureEOL() {
+	if !this.eol {
+		this.writeText("\n")
+	}
+}
+
+// isLive 判断 layout 对应的 section 是否仍然存在
+func (this *iniParser) isLive(layout *sectionLayout) bool {
+	var s, _ = this.sections.Load(layout.section.name)
+	return s == layout.section
+}
+
+// layoutsOf 返回 section 在源文件中的每一段
+func (this *iniParser) layoutsOf(section *Section) []*sectionLayout {
+	var layouts []*sectionLayout
+	for _, layout := range this.layout {
+		if layout.section == section {
+			layouts = append(layouts, layout)
+		}
+	}
+	return layouts
+}
+
+// writeLayoutTo 按照源文件中的顺序写入 layouts, 返回已经写入的 section
+func (this *iniParser) writeLayoutTo(writer *iniWriter, layouts []*sectionLayout) map[*Section]bool {
+	var groups = make(map[*Section][]*sectionLayout)
+	for _, layout := range layouts {
+		if layout.section != nil && this.isLive(layout) {
+			groups[layout.section] = append(groups[layout.section], layout)
+		}
+	}
+
+	var lines = make(map[*Section]map[*Option]*optionLines)
+	for section, group := range groups {
+		lines[section] = section.optionLines(group)
+	}
+
+	var written = make(map[*Section]bool)
+	for _, layout := range layouts {
+		if layout.section == nil {
+			writer.ensureEOL()
+			for _, line := range layout.lead {
+				writer.WriteString(line)
+			}
+			continue
+		}
+
+		var group = groups[layout.section]
+		if group == nil {
+			continue
+		}
+
+		var section = layout.section
+		var comments []string
+		if layout == group[0] {
+			// 加载之后添加的注释, 加载时的注释已经包含在原始文本中
+			var loaded = 0
+			for _, l := range group {
+				if l.comments > loaded {
+					loaded = l.comments
+				}
+			}
+			comments = section.Comments()[loaded:]
+		}
+		// 新增的 option 写在 section 最后一段的末尾
+		section.writeLayoutTo(writer, layout, comments, lines[section], layout == group[len(group)-1])
+		written[section] = true
+	}
+	return written
+}
+
+func writeComments(writer *iniWriter, comments []string) {
+	for _, c := range comments {
+		if len(strings.TrimSpace(c)) > 0 {
+			writer.ensureEOL()
+			writer.writeText(fmt.Sprintf("# %s\n", c))
+		}
+	}
+}
+
+// isLive 判断 line 对应的值是否仍然存在
+func (this *Section) isLive(line *lineLayout) bool {
+	var opt, _ = this.options.Load(line.option.key)
+	if opt != line.option {
+		return false
+	}
+	var _, ok = line.option.rawValue(line.index)
+	return ok
+}
+
+// optionLines 记录 option 在源文件中第一个和最后一个仍然存在的值, 以及加载时的注释数量
+type optionLines struct {
+	first    *lineLayout
+	last     *lineLayout
+	comments int
+}
+
+func (this *Section) optionLines(layouts []*sectionLayout) map[*Option]*optionLines {
+	var lines = make(map[*Option]*optionLines)
+	for _, layout := range layouts {
+		for _, line := range layout.lines {
+			if !this.isLive(line) {
+				continue
+			}
+			var ol = lines[line.option]
+			if ol == nil {
+				ol = &optionLines{first: line}
+				lines[line.option] = ol
+			}
+			ol.last = line
+			if line.comments > ol.comments {
+				ol.comments = line.comments
+			}
+		}
+	}
+	return lines
+}
+
+// writeLayoutTo 写入 section 在源文件中的一段, comments 为加载之后添加的注释, final 表示是否为最后一段
+func (this *Section) writeLayoutTo(writer *iniWriter, layout *sectionLayout, comments []string, lines map[*Option]*optionLines, final bool) {
+	// 上一个文件可能没有以换行符结尾
+	writer.ensureEOL()
+	for _, line := range layout.lead {
+		writer.WriteString(line)
+	}
+	writeComments(writer, comments)
+	writer.WriteString(layout.header)
+
+	for _, line := range layout.lines {
+		if !this.isLive(line) {
+			continue
+		}
+
+		for _, l := range line.before {
+			writer.WriteString(l)
+		}
+
+		var opt = line.option
+		var ol = lines[opt]
+		if ol.first == line {
+			writeComments(writer, opt.Comments()[ol.comments:])
+		}
+
+		var values = opt.rawValues()
+		var value = values[line.index]
+		var suffix = line.suffix
+		if inlineComment := opt.InlineComment(); line.index == 0 && inlineComment != line.comment {
+			suffix = writer.encodeComment(inlineComment)
+		}
+
+		if value == line.value && suffix == line.suffix {
+			writer.WriteString(line.raw)
+		} else {
+			writer.WriteString(line.prefix + writer.encodeValue(value, line.indent, writer.lineEOL(line.eol)) + suffix + line.eol)
+		}
+
+		if ol.last == line {
+			// 新增的值沿用最后一行的格式, 行内注释只属于第一个值
+			var prefix = strings.TrimPrefix(line.prefix, kBOM)
+			for _, value := range values[line.index+1:] {
+				writer.ensureEOL()
+				writer.writeText(prefix + writer.encodeValue(value, line.indent, "\n") + "\n")
+			}
+		}
+	}
+
+	if !final {
+		return
+	}
+	for _, opt := range this.orderedOptions() {
+		if _, ok := lines[opt]; ok {
+			continue
+		}
+		writer.ensureEOL()
+		writeOption(writer, opt)
+	}
+}
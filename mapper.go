@@ -0,0 +1,290 @@
+package ini4go
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Unmarshal 解析 data 并将结果填充到 v 中, v 必须为结构体指针, 规则同 MapTo
+func Unmarshal(data []byte, v interface{}) error {
+	var r = New(false)
+	if err := r.LoadBytes("bytes", data); err != nil {
+		return err
+	}
+	return r.MapTo(v)
+}
+
+// Marshal 将结构体 v 转换为配置文件内容, 规则同 ReflectFrom
+func Marshal(v interface{}) ([]byte, error) {
+	var r = New(false)
+	if err := r.ReflectFrom(v); err != nil {
+		return nil, err
+	}
+
+	return r.Bytes(), nil
+}
+
+// MapTo 将配置填充到 v 中, v 必须为结构体指针。
+//
+// 字段名称由 ini 标签指定, 未指定时使用字段名, ini:"-" 表示忽略该字段;
+// 结构体类型的字段对应同名的 section, 其它字段对应所在 section 中的 option, 顶层结构体的 option 位于 default section;
+// slice 类型的字段对应有多个值的 option;
+// time.Time 类型的字段可以通过 layout 标签指定格式;
+// option 不存在时使用 default 标签中的值, slice 类型的默认值用逗号分隔。
+func (this *Ini) MapTo(v interface{}) error {
+	var rv = reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("ini4go: MapTo 需要一个非 nil 的结构体指针")
+	}
+
+	this.RLock()
+	defer this.RUnlock()
+
+	return this.mapTo(kDefaultSection, rv.Elem())
+}
+
+func (this *iniParser) mapTo(section string, rv reflect.Value) error {
+	var rt = rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		var field = rt.Field(i)
+		var fv = rv.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := this.mapTo(section, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var name, ok = fieldName(field)
+		if !ok {
+			continue
+		}
+
+		if isSectionType(field.Type) {
+			if err := this.mapTo(name, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var values = this.values(section, name)
+		if len(values) == 0 || (len(values) == 1 && values[0] == "" && fv.Kind() != reflect.String) {
+			var defaultValue, ok = field.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			values = []string{defaultValue}
+			if fv.Kind() == reflect.Slice {
+				values = strings.Split(defaultValue, ",")
+			}
+		}
+
+		if err := setField(fv, values, field.Tag.Get("layout")); err != nil {
+			return fmt.Errorf("ini4go: [%s] %s: %v", section, name, err)
+		}
+	}
+	return nil
+}
+
+// ReflectFrom 根据结构体 v 设置配置, v 可以是结构体或者结构体指针。
+//
+// 字段与 section、option 的对应规则同 MapTo, slice 类型的字段写为多个同名的 option,
+// comment 标签的内容作为 section 或者 option 的注释。
+func (this *Ini) ReflectFrom(v interface{}) error {
+	var rv = reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errors.New("ini4go: ReflectFrom 需要一个结构体或者结构体指针")
+	}
+
+	this.Lock()
+	defer this.Unlock()
+
+	return this.reflectFrom(kDefaultSection, rv)
+}
+
+func (this *iniParser) reflectFrom(section string, rv reflect.Value) error {
+	var rt = rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		var field = rt.Field(i)
+		var fv = rv.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := this.reflectFrom(section, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var name, ok = fieldName(field)
+		if !ok {
+			continue
+		}
+
+		var comment = field.Tag.Get("comment")
+
+		if isSectionType(field.Type) {
+			var s = this.newSection(name)
+			if comment != "" && s.Comment() == "" {
+				s.AddComment(comment)
+			}
+			if err := this.reflectFrom(name, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var values []string
+		if fv.Kind() == reflect.Slice {
+			if fv.Len() == 0 {
+				continue
+			}
+			for j := 0; j < fv.Len(); j++ {
+				var value, err = formatValue(fv.Index(j), field.Tag.Get("layout"))
+				if err != nil {
+					return fmt.Errorf("ini4go: [%s] %s: %v", section, name, err)
+				}
+				values = append(values, value)
+			}
+		} else {
+			var value, err = formatValue(fv, field.Tag.Get("layout"))
+			if err != nil {
+				return fmt.Errorf("ini4go: [%s] %s: %v", section, name, err)
+			}
+			values = append(values, value)
+		}
+
+		var opt = this.newSection(section).newOption(name, "=")
+		opt.setValues(values)
+		if comment != "" && opt.Comment() == "" {
+			opt.AddComment(comment)
+		}
+	}
+	return nil
+}
+
+// fieldName 返回字段对应的名称, 未导出或者标记为忽略的字段返回 false
+func fieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+
+	var name = field.Tag.Get("ini")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+func isSectionType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+func setField(fv reflect.Value, values []string, layout string) error {
+	if fv.Kind() == reflect.Slice {
+		var list = reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(list.Index(i), strings.TrimSpace(value), layout); err != nil {
+				return err
+			}
+		}
+		fv.Set(list)
+		return nil
+	}
+	return setValue(fv, values[0], layout)
+}
+
+func setValue(v reflect.Value, raw, layout string) error {
+	switch v.Type() {
+	case timeType:
+		if layout == "" {
+			layout = kTimeLayout
+		}
+		var t, err = time.Parse(layout, raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		var d, err = time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		var b, err = parseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i, err = strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u, err = strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f, err = strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("不支持的类型 %s", v.Type())
+	}
+	return nil
+}
+
+func formatValue(v reflect.Value, layout string) (string, error) {
+	switch v.Type() {
+	case timeType:
+		if layout == "" {
+			layout = kTimeLayout
+		}
+		return v.Interface().(time.Time).Format(layout), nil
+	case durationType:
+		return time.Duration(v.Int()).String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("不支持的类型 %s", v.Type())
+}
@@ -0,0 +1,116 @@
+package ini4go
+
+import (
+	"testing"
+	"time"
+)
+
+type testServerConfig struct {
+	Host    string        `ini:"host"`
+	Port    int           `ini:"port" default:"8080"`
+	Timeout time.Duration `ini:"timeout" default:"5s"`
+	Debug   bool          `ini:"debug"`
+}
+
+type testDatabaseConfig struct {
+	Hosts   []string  `ini:"host"`
+	Weights []float64 `ini:"weight"`
+	MaxConn int64     `ini:"max_conn"`
+	Created time.Time `ini:"created" layout:"2006-01-02"`
+	Ignored string    `ini:"-"`
+}
+
+type testConfig struct {
+	Name     string             `ini:"name"`
+	Server   testServerConfig   `ini:"server"`
+	Database testDatabaseConfig `ini:"database"`
+}
+
+func TestMapTo(t *testing.T) {
+	var src = `
+name = app
+
+[server]
+host = localhost
+debug = on
+
+[database]
+host = db1
+host = db2
+weight = 0.5
+weight = 1.5
+max_conn = 64
+created = 2020-06-06
+Ignored = oops
+`
+	var cfg testConfig
+	if err := Unmarshal([]byte(src), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Name != "app" {
+		t.Error("name 应该为 app")
+	}
+	if cfg.Server.Host != "localhost" || cfg.Server.Port != 8080 || cfg.Server.Timeout != 5*time.Second || !cfg.Server.Debug {
+		t.Error("server 映射错误", cfg.Server)
+	}
+	if len(cfg.Database.Hosts) != 2 || cfg.Database.Hosts[1] != "db2" {
+		t.Error("database -> host 应该有两个值", cfg.Database.Hosts)
+	}
+	if len(cfg.Database.Weights) != 2 || cfg.Database.Weights[1] != 1.5 {
+		t.Error("database -> weight 映射错误", cfg.Database.Weights)
+	}
+	if cfg.Database.MaxConn != 64 || cfg.Database.Created.Day() != 6 || cfg.Database.Ignored != "" {
+		t.Error("database 映射错误", cfg.Database)
+	}
+
+	if err := Unmarshal([]byte("[server]\nport = abc\n"), &cfg); err == nil {
+		t.Error("port 不是数字, 应该返回错误")
+	}
+}
+
+type testCommentConfig struct {
+	Name   string           `ini:"name" comment:"应用名称"`
+	Server testServerConfig `ini:"server" comment:"服务配置"`
+	Tags   []string         `ini:"tag"`
+}
+
+func TestMarshal(t *testing.T) {
+	var cfg = testCommentConfig{}
+	cfg.Name = "app"
+	cfg.Server.Host = "localhost"
+	cfg.Server.Port = 80
+	cfg.Server.Timeout = time.Minute
+	cfg.Tags = []string{"a", "b"}
+
+	var data, err = Marshal(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = `[default]
+
+# 应用名称
+name = app
+tag = a
+tag = b
+
+# 服务配置
+[server]
+host = localhost
+port = 80
+timeout = 1m0s
+debug = false
+`
+	if string(data) != expected {
+		t.Errorf("Marshal 输出错误:\n%s", data)
+	}
+
+	var out testCommentConfig
+	if err = Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Server != cfg.Server || len(out.Tags) != 2 || out.Name != cfg.Name {
+		t.Error("Marshal 之后 Unmarshal 的结果不一致", out)
+	}
+}
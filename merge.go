@@ -0,0 +1,216 @@
+package ini4go
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type MergeStrategy int
+
+const (
+	// MergeOverride 两边都存在的 option 使用 other 中的值
+	MergeOverride MergeStrategy = iota
+
+	// MergeAppend 两边都存在的 option 将 other 中的值添加到已有的值之后, 与 LoadFiles 加载重复的 option 相同
+	MergeAppend
+
+	// MergeKeepExisting 两边都存在的 option 保留已有的值, 只添加新的 section 和 option
+	MergeKeepExisting
+
+	// MergeErrorOnConflict 两边都存在并且值不同的 option 作为冲突, 有冲突时返回 *MergeConflictError 并且不做任何修改
+	MergeErrorOnConflict
+)
+
+// MergeConflict 描述使用 MergeErrorOnConflict 合并时两边的值不同的 option
+type MergeConflict struct {
+	Section     string
+	Option      string
+	Values      []string
+	OtherValues []string
+}
+
+type MergeConflictError struct {
+	Conflicts []MergeConflict
+}
+
+func (this *MergeConflictError) Error() string {
+	var lines = make([]string, 0, len(this.Conflicts))
+	for _, c := range this.Conflicts {
+		lines = append(lines, fmt.Sprintf("[%s] %s: %q != %q", c.Section, c.Option, c.Values, c.OtherValues))
+	}
+	return "ini4go: 合并的配置存在冲突:\n" + strings.Join(lines, "\n")
+}
+
+// Clone 返回配置的副本, 包括 section 和 option 的顺序、值、注释、来源以及保留的格式, 修改副本不会影响原来的配置;
+// OnChange 注册的回调函数不会被复制
+func (this *Ini) Clone() *Ini {
+	this.RLock()
+	defer this.RUnlock()
+
+	var c = &Ini{}
+	c.block = this.block
+	c.init()
+	this.copySettings(&c.iniParser)
+	c.loadCount = this.loadCount
+	c.sources = append([]string(nil), this.sources...)
+	c.eol = this.eol
+
+	var sections = make(map[*Section]*Section)
+	var options = make(map[*Option]*Option)
+	for _, name := range this.sectionKeys {
+		var s = this.section(name)
+		var ns = c.newSection(name)
+		s.cloneTo(ns, options)
+		sections[s] = ns
+	}
+
+	// 已经删除的 section 和 option 对应的文本不会再写入, 不需要复制
+	for _, layout := range this.layout {
+		var ns, ok = sections[layout.section]
+		if !ok && layout.section != nil {
+			continue
+		}
+
+		var nLayout = &sectionLayout{}
+		nLayout.section = ns
+		nLayout.lead = append([]string(nil), layout.lead...)
+		nLayout.header = layout.header
+		nLayout.comments = layout.comments
+		for _, line := range layout.lines {
+			if nOpt, ok := options[line.option]; ok {
+				var l = *line
+				l.option = nOpt
+				nLayout.lines = append(nLayout.lines, &l)
+			}
+		}
+		c.layout = append(c.layout, nLayout)
+	}
+	return c
+}
+
+// cloneTo 将 section 中的内容复制到 dst, options 记录原来的 option 与复制之后的 option 的对应关系
+func (this *Section) cloneTo(dst *Section, options map[*Option]*Option) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	dst.comments = append([]string(nil), this.comments...)
+	dst.origin = this.origin
+
+	for _, key := range this.optionKeys {
+		var v, _ = this.options.Load(key)
+		var opt = v.(*Option)
+		var nOpt = dst.newOption(key, opt.iv)
+		opt.cloneTo(nOpt)
+		options[opt] = nOpt
+	}
+}
+
+func (this *Option) cloneTo(dst *Option) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	dst.values = append([]string(nil), this.values...)
+	dst.comments = append([]string(nil), this.comments...)
+	dst.inlineComment = this.inlineComment
+	dst.origins = append([]Origin(nil), this.origins...)
+}
+
+// Merge 将 other 中的 section 和 option 合并到当前配置中, strategy 决定两边都存在的 option 如何处理,
+// 新的 section 和 option 按照在 other 中的顺序添加到最后
+func (this *Ini) Merge(other *Ini, strategy MergeStrategy) error {
+	// 先复制 other, 避免同时持有两个配置的锁
+	var src = other.Clone()
+
+	this.Lock()
+	defer this.Unlock()
+
+	if strategy == MergeErrorOnConflict {
+		if conflicts := this.conflictsWith(src); len(conflicts) > 0 {
+			return &MergeConflictError{Conflicts: conflicts}
+		}
+	}
+
+	for _, name := range src.sectionKeys {
+		var s = src.section(name)
+		var exists = this.section(name) != nil
+		var section = this.newSection(name)
+		if !exists || len(section.Comments()) == 0 {
+			for _, c := range s.comments {
+				section.AddComment(c)
+			}
+		}
+		if !exists {
+			section.initOrigin(s.origin)
+		}
+
+		for _, opt := range s.orderedOptions() {
+			var current = section.Option(opt.key)
+			if current == nil {
+				current = section.newOption(opt.key, opt.iv)
+				current.replaceValues(opt.values, opt.origins)
+				current.AddComment(opt.comments...)
+				current.SetInlineComment(opt.inlineComment)
+				continue
+			}
+
+			switch strategy {
+			case MergeOverride:
+				current.replaceValues(opt.values, opt.origins)
+			case MergeAppend:
+				current.appendValues(opt.values, opt.origins)
+			}
+			if len(current.Comments()) == 0 {
+				current.AddComment(opt.comments...)
+			}
+		}
+	}
+	return nil
+}
+
+// conflictsWith 返回两边都存在并且值不同的 option
+func (this *iniParser) conflictsWith(other *Ini) []MergeConflict {
+	var conflicts []MergeConflict
+	for _, name := range other.sectionKeys {
+		var section = this.section(name)
+		if section == nil {
+			continue
+		}
+		for _, opt := range other.section(name).orderedOptions() {
+			var current = section.Option(opt.key)
+			if current == nil {
+				continue
+			}
+			var values = current.rawValues()
+			if !reflect.DeepEqual(values, opt.values) {
+				conflicts = append(conflicts, MergeConflict{Section: name, Option: opt.key, Values: values, OtherValues: opt.values})
+			}
+		}
+	}
+	return conflicts
+}
+
+// replaceValues 使用 values 替换已有的值, origins 为对应的来源
+func (this *Option) replaceValues(values []string, origins []Origin) {
+	this.lock()
+	defer this.unlock()
+
+	this.values = append([]string(nil), values...)
+	this.origins = append([]Origin(nil), origins...)
+	this.modified()
+}
+
+// appendValues 将 values 添加到已有的值之后, origins 为对应的来源
+func (this *Option) appendValues(values []string, origins []Origin) {
+	this.lock()
+	defer this.unlock()
+
+	if len(this.origins) > 0 || len(origins) > 0 {
+		for len(this.origins) < len(this.values) {
+			this.origins = append(this.origins, Origin{})
+		}
+		this.origins = append(this.origins[:len(this.values)], origins...)
+	}
+	this.values = append(this.values, values...)
+	this.modified()
+}
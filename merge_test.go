@@ -0,0 +1,101 @@
+package ini4go
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	var src = `# 服务
+[server]
+host = a
+; 端口
+port = 80
+
+[db]
+host = h1
+host = h2
+`
+	var r = New(false)
+	r.SetPreserveFormat(true)
+	r.LoadString("app.conf", src)
+
+	var c = r.Clone()
+	if c.String() != src {
+		t.Errorf("副本的输出应该与原配置相同:\n%s", c.String())
+	}
+	if fmt.Sprint(c.SectionNames()) != "[server db]" || fmt.Sprint(c.Options("server")) != "[host port]" {
+		t.Error("副本中 section 和 option 的顺序应该与原配置相同")
+	}
+	if c.Section("server").Comment() != "服务" || c.Option("server", "port").Comment() != "端口" {
+		t.Error("副本应该包含注释")
+	}
+	if c.Option("db", "host").OriginAt(1).Line != 9 {
+		t.Error("副本应该包含来源", c.Option("db", "host").OriginAt(1))
+	}
+
+	c.SetValue("server", "port", "8080")
+	c.Option("db", "host").AddValue("h3")
+	c.Section("server").AddComment("其它")
+	if r.GetValue("server", "port") != "80" || len(r.GetValues("db", "host")) != 2 || len(r.Section("server").Comments()) != 1 {
+		t.Error("修改副本不应该影响原配置")
+	}
+	if !strings.Contains(c.String(), "port = 8080\n") || r.String() != src {
+		t.Error("副本和原配置的输出应该各自独立")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var newConfig = func(src string) *Ini {
+		var r = New(false)
+		r.LoadString("app.conf", src)
+		return r
+	}
+	var base = "[server]\nhost = a\nport = 80\n"
+	var other = newConfig("[server]\nport = 8080\ndebug = true\n\n[db]\nhost = h1\n")
+
+	var r = newConfig(base)
+	if err := r.Merge(other, MergeOverride); err != nil {
+		t.Fatal(err)
+	}
+	if r.GetValue("server", "port") != "8080" || r.GetValue("server", "debug") != "true" || r.GetValue("db", "host") != "h1" {
+		t.Error("MergeOverride 应该使用 other 中的值")
+	}
+	if fmt.Sprint(r.Options("server")) != "[host port debug]" || fmt.Sprint(r.SectionNames()) != "[server db]" {
+		t.Error("新的 option 和 section 应该添加到最后", r.Options("server"), r.SectionNames())
+	}
+
+	r = newConfig(base)
+	r.Merge(other, MergeAppend)
+	if fmt.Sprint(r.GetValues("server", "port")) != "[80 8080]" {
+		t.Error("MergeAppend 应该添加 other 中的值", r.GetValues("server", "port"))
+	}
+
+	r = newConfig(base)
+	r.Merge(other, MergeKeepExisting)
+	if r.GetValue("server", "port") != "80" || r.GetValue("server", "debug") != "true" {
+		t.Error("MergeKeepExisting 应该保留已有的值")
+	}
+
+	r = newConfig(base)
+	var err = r.Merge(other, MergeErrorOnConflict)
+	var cErr *MergeConflictError
+	if !errors.As(err, &cErr) || len(cErr.Conflicts) != 1 || cErr.Conflicts[0].Option != "port" {
+		t.Fatal("应该返回 port 的冲突", err)
+	}
+	if r.HasSection("db") || r.GetValue("server", "port") != "80" {
+		t.Error("有冲突时不应该修改配置")
+	}
+	if err = r.Merge(newConfig("[server]\nport = 80\n[db]\nhost = h1\n"), MergeErrorOnConflict); err != nil {
+		t.Error("值相同的 option 不是冲突", err)
+	}
+
+	// other 为自身
+	r = newConfig(base)
+	r.Merge(r, MergeAppend)
+	if fmt.Sprint(r.GetValues("server", "host")) != "[a a]" {
+		t.Error("合并自身", r.GetValues("server", "host"))
+	}
+}
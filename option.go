@@ -3,25 +3,26 @@ package ini4go
 import (
 	"errors"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-var varRegexp = regexp.MustCompile(`%\((\S[^\(\)]+)\)s`)
-
-func getVarName(src string) [][]string {
-	var rList = varRegexp.FindAllStringSubmatch(src, -1)
-	return rList
-}
+const (
+	kTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+)
 
+// Option 的值和注释由自身的锁保护, 可以在多个 goroutine 中同时读写
 type Option struct {
-	section  *Section
-	key      string
-	iv       string
-	values   []string
-	comments []string
+	mutex         sync.RWMutex
+	section       *Section
+	key           string
+	iv            string
+	values        []string
+	comments      []string
+	inlineComment string
+	origins       []Origin
 }
 
 func NewOption(section *Section, key, iv string, values []string) *Option {
@@ -38,10 +39,18 @@ func (this *Option) Key() string {
 }
 
 func (this *Option) Comments() []string {
-	return this.comments
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	var comments = make([]string, len(this.comments))
+	copy(comments, this.comments)
+	return comments
 }
 
 func (this *Option) Comment() string {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
 	if len(this.comments) > 0 {
 		return this.comments[0]
 	}
@@ -50,58 +59,168 @@ func (this *Option) Comment() string {
 
 func (this *Option) AddComment(comment ...string) {
 	if len(comment) > 0 {
+		this.mutex.Lock()
 		this.comments = append(this.comments, comment...)
+		this.mutex.Unlock()
 	}
 }
 
-func (this *Option) parseValue(raw string) (result string) {
-	//var varNameList = getVarName(raw)
-	//result = raw
-	//for _, l := range varNameList {
-	//	var keyVar = l[0]
-	//	var keyName = l[1]
-	//	var value = this.section.MustOption(keyName).Value()
-	//	result = strings.Replace(raw, keyVar, value, -1)
-	//}
-	//return result
+// InlineComment 返回与值写在同一行的注释
+func (this *Option) InlineComment() string {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	return this.inlineComment
+}
 
-	result = varRegexp.ReplaceAllStringFunc(raw, func(src string) string {
-		var key = src[2 : len(src)-2]
-		var value = this.section.MustOption(key).Value()
-		return value
-	})
-	return result
+func (this *Option) SetInlineComment(comment string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.inlineComment = comment
 }
 
 func (this *Option) Value() string {
 	return this.ValueAt(0)
 }
 
+// ValueAt 返回第 index 个值替换变量之后的结果, 变量替换失败时返回原始的值
 func (this *Option) ValueAt(index int) string {
-	if len(this.values) > index {
-		return this.parseValue(this.values[index])
+	var raw, ok = this.rawValue(index)
+	if !ok {
+		return ""
 	}
-	return ""
+	var value, err = this.parseValue(raw, []string{this.id()})
+	if err != nil {
+		return raw
+	}
+	return value
+}
+
+// Resolve 返回第一个值替换变量之后的结果, 变量替换失败时返回 *InterpolationError
+func (this *Option) Resolve() (string, error) {
+	return this.ResolveAt(0)
+}
+
+func (this *Option) ResolveAt(index int) (string, error) {
+	var raw, ok = this.rawValue(index)
+	if !ok {
+		return "", nil
+	}
+	return this.parseValue(raw, []string{this.id()})
 }
 
 func (this *Option) Values() []string {
-	var l = len(this.values)
-	var newValues = make([]string, l)
+	var newValues = this.rawValues()
 
-	for i := 0; i < l; i++ {
-		newValues[i] = this.ValueAt(i)
+	for i, raw := range newValues {
+		if value, err := this.parseValue(raw, []string{this.id()}); err == nil {
+			newValues[i] = value
+		}
 	}
 
 	return newValues
 }
 
+// rawValue 返回第 index 个原始的值, 变量替换在锁之外进行, 避免引用其它 option 时嵌套加锁
+func (this *Option) rawValue(index int) (string, bool) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	if index >= 0 && index < len(this.values) {
+		return this.values[index], true
+	}
+	return "", false
+}
+
+// rawValues 返回所有原始的值的副本
+func (this *Option) rawValues() []string {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	var values = make([]string, len(this.values))
+	copy(values, this.values)
+	return values
+}
+
 func (this *Option) SetValue(v string) {
-	this.values = []string{v}
+	this.setValues([]string{v})
+}
+
+func (this *Option) setValues(values []string) {
+	this.lock()
+	defer this.unlock()
+
+	this.values = values
+	this.origins = nil
+	this.modified()
+}
+
+// addParsedValue 添加从文件中解析出来的值, 返回该值的索引
+func (this *Option) addParsedValue(value, inlineComment string, origin Origin) int {
+	this.lock()
+	defer this.unlock()
+
+	this.values = append(this.values, value)
+	for len(this.origins) < len(this.values)-1 {
+		this.origins = append(this.origins, Origin{})
+	}
+	this.origins = append(this.origins[:len(this.values)-1], origin)
+	if this.inlineComment == "" {
+		this.inlineComment = inlineComment
+	}
+	this.modified()
+	return len(this.values) - 1
+}
+
+// appendLine 在第 index 个值之后添加一行, 返回新的值
+func (this *Option) appendLine(index int, line string) string {
+	this.lock()
+	defer this.unlock()
+
+	this.values[index] += "\n" + line
+	this.modified()
+	return this.values[index]
+}
+
+// lock 在修改值之前调用, 除了自身的锁之外还会持有配置的 writes 读锁, 见 Snapshot
+func (this *Option) lock() {
+	this.section.lockWrites()
+	this.mutex.Lock()
+}
+
+func (this *Option) unlock() {
+	this.mutex.Unlock()
+	this.section.unlockWrites()
+}
+
+// modified 在值修改之后、释放锁之前调用, 读到新值的 Snapshot 一定能发现版本的变化
+func (this *Option) modified() {
+	if this.section != nil {
+		this.section.modified()
+	}
+}
+
+// Origin 返回第一个值的来源
+func (this *Option) Origin() Origin {
+	return this.OriginAt(0)
+}
+
+// OriginAt 返回第 index 个值的来源, 不是从文件中加载的值返回 Origin 的零值
+func (this *Option) OriginAt(index int) Origin {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	if index >= 0 && index < len(this.origins) {
+		return this.origins[index]
+	}
+	return Origin{}
 }
 
 func (this *Option) AddValue(v ...string) {
 	if len(v) > 0 {
+		this.lock()
 		this.values = append(this.values, v...)
+		this.modified()
+		this.unlock()
 	}
 }
 
@@ -110,8 +229,8 @@ func (this *Option) String() string {
 }
 
 func (this *Option) MustString(defaultValue string) string {
-	if len(this.String()) > 0 {
-		return this.String()
+	if v := this.String(); len(v) > 0 {
+		return v
 	}
 	return defaultValue
 }
@@ -121,7 +240,10 @@ func (this *Option) SetString(s string) {
 }
 
 func (this *Option) Int() (int, error) {
-	var v = this.String()
+	var v, err = this.Resolve()
+	if err != nil {
+		return 0, err
+	}
 	return strconv.Atoi(v)
 }
 
@@ -139,7 +261,10 @@ func (this *Option) SetInt(v int) {
 }
 
 func (this *Option) Int64() (int64, error) {
-	var v = this.String()
+	var v, err = this.Resolve()
+	if err != nil {
+		return 0, err
+	}
 	return strconv.ParseInt(v, 10, 64)
 }
 
@@ -157,8 +282,12 @@ func (this *Option) SetInt64(v int64) {
 }
 
 func (this *Option) Float32() (float32, error) {
-	var v = this.String()
-	var fv, err = strconv.ParseFloat(v, 32)
+	var v, err = this.Resolve()
+	if err != nil {
+		return 0, err
+	}
+	var fv float64
+	fv, err = strconv.ParseFloat(v, 32)
 	return float32(fv), err
 }
 
@@ -176,7 +305,10 @@ func (this *Option) SetFloat32(v float32) {
 }
 
 func (this *Option) Float64() (float64, error) {
-	var v = this.String()
+	var v, err = this.Resolve()
+	if err != nil {
+		return 0, err
+	}
 	return strconv.ParseFloat(v, 64)
 }
 
@@ -194,7 +326,15 @@ func (this *Option) SetFloat64(v float64) {
 }
 
 func (this *Option) Bool() (bool, error) {
-	var v = strings.ToLower(this.String())
+	var v, err = this.Resolve()
+	if err != nil {
+		return false, err
+	}
+	return parseBool(v)
+}
+
+func parseBool(s string) (bool, error) {
+	var v = strings.ToLower(s)
 	switch v {
 	case "1", "true", "yes", "on", "t", "y":
 		return true, nil
@@ -218,7 +358,7 @@ func (this *Option) SetBool(v bool) {
 }
 
 func (this *Option) Time() (time.Time, error) {
-	return this.TimeWithLayout("2006-01-02 15:04:05.999999999 -0700 MST")
+	return this.TimeWithLayout(kTimeLayout)
 }
 
 func (this *Option) MustTime(defaultValue time.Time) time.Time {
@@ -235,7 +375,10 @@ func (this *Option) SetTime(v time.Time) {
 }
 
 func (this *Option) TimeWithLayout(layout string) (time.Time, error) {
-	var v = this.String()
+	var v, err = this.Resolve()
+	if err != nil {
+		return time.Time{}, err
+	}
 	return time.Parse(layout, v)
 }
 
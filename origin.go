@@ -0,0 +1,48 @@
+package ini4go
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Origin 描述 section 或者值的来源
+type Origin struct {
+	Source string // 文件路径, 或者 LoadReader 等方法传入的名称
+	Line   int    // 所在的行, 从 1 开始计数
+	Order  int    // 加载的顺序, 从 1 开始计数, 0 表示不是从文件中加载的
+}
+
+func (this Origin) IsZero() bool {
+	return this.Order == 0
+}
+
+func (this Origin) String() string {
+	if this.IsZero() {
+		return "-"
+	}
+	return fmt.Sprintf("%s:%d (#%d)", this.Source, this.Line, this.Order)
+}
+
+// Dump 输出所有 section 和值, 以及它们的来源, 用于调试
+func (this *iniParser) Dump(w io.Writer) error {
+	this.RLock()
+	defer this.RUnlock()
+
+	var writer = bufio.NewWriter(w)
+	for index, sectionName := range this.sectionKeys {
+		if index > 0 {
+			writer.WriteString("\n")
+		}
+
+		var section = this.section(sectionName)
+		fmt.Fprintf(writer, "[%s] ; %s\n", sectionName, section.Origin())
+
+		for _, opt := range section.orderedOptions() {
+			for i, value := range opt.rawValues() {
+				fmt.Fprintf(writer, "%s = %s ; %s\n", opt.key, value, opt.OriginAt(i))
+			}
+		}
+	}
+	return writer.Flush()
+}
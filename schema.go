@@ -0,0 +1,254 @@
+package ini4go
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ValueType int
+
+const (
+	TypeString ValueType = iota
+	TypeInt
+	TypeFloat
+	TypeBool
+	TypeDuration
+	TypeEnum
+)
+
+func (this ValueType) String() string {
+	switch this {
+	case TypeString:
+		return "string"
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeBool:
+		return "bool"
+	case TypeDuration:
+		return "duration"
+	case TypeEnum:
+		return "enum"
+	}
+	return fmt.Sprintf("ValueType(%d)", int(this))
+}
+
+// Range 限制数值的范围, TypeDuration 使用纳秒, 如 Range{Min: float64(time.Second), Max: float64(time.Minute)}
+type Range struct {
+	Min float64
+	Max float64
+}
+
+type OptionSchema struct {
+	Name     string
+	Type     ValueType
+	Required bool
+
+	// TypeInt、TypeFloat、TypeDuration 的取值范围
+	Range *Range
+
+	// TypeEnum 允许的值
+	Enum []string
+
+	// 值需要匹配的正则表达式
+	Pattern *regexp.Regexp
+
+	// 是否允许有多个值, 以及值的个数范围, 0 表示不限制
+	Multiple  bool
+	MinValues int
+	MaxValues int
+}
+
+// SectionSchema 定义 section 中的 option, section 不存在并且不是必须的时候不检查其中的 option
+type SectionSchema struct {
+	Name     string
+	Required bool
+	Options  []OptionSchema
+}
+
+// Schema 定义配置中的 section、option 以及值的类型和范围
+type Schema struct {
+	Sections []SectionSchema
+
+	// 不允许出现 Schema 中没有定义的 section 和 option
+	Strict bool
+}
+
+// Violation 描述配置中不符合 Schema 的一处, Origin 为对应的 section 或者值的来源
+type Violation struct {
+	Section string
+	Option  string
+	Origin  Origin
+	Message string
+}
+
+func (this *Violation) String() string {
+	var name = fmt.Sprintf("[%s]", this.Section)
+	if this.Option != "" {
+		name += " " + this.Option
+	}
+	if this.Origin.IsZero() {
+		return fmt.Sprintf("%s: %s", name, this.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", this.Origin, name, this.Message)
+}
+
+// ValidationError 包含所有不符合 Schema 的地方
+type ValidationError struct {
+	Violations []*Violation
+}
+
+func (this *ValidationError) Error() string {
+	var lines = make([]string, 0, len(this.Violations))
+	for _, v := range this.Violations {
+		lines = append(lines, v.String())
+	}
+	return "ini4go: 配置不符合 Schema:\n" + strings.Join(lines, "\n")
+}
+
+// Validate 检查配置是否符合 Schema, 不符合时返回 *ValidationError
+func (this *Schema) Validate(c *Ini) error {
+	c.RLock()
+	defer c.RUnlock()
+
+	var violations []*Violation
+	var report = func(section, option string, origin Origin, format string, args ...interface{}) {
+		violations = append(violations, &Violation{Section: section, Option: option, Origin: origin, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var known = make(map[string]*SectionSchema)
+	for i := range this.Sections {
+		var ss = &this.Sections[i]
+		known[ss.Name] = ss
+
+		var v, _ = c.sections.Load(ss.Name)
+		if v == nil {
+			if ss.Required {
+				report(ss.Name, "", Origin{}, "缺少 section")
+			}
+			continue
+		}
+
+		var section = v.(*Section)
+		var knownOptions = make(map[string]bool)
+		for _, optSchema := range ss.Options {
+			knownOptions[optSchema.Name] = true
+
+			var opt, _ = section.options.Load(optSchema.Name)
+			if opt == nil {
+				if optSchema.Required {
+					report(ss.Name, optSchema.Name, section.Origin(), "缺少 option")
+				}
+				continue
+			}
+			optSchema.validate(opt.(*Option), report)
+		}
+
+		if this.Strict {
+			for _, opt := range section.orderedOptions() {
+				if !knownOptions[opt.key] {
+					report(ss.Name, opt.key, opt.Origin(), "未定义的 option")
+				}
+			}
+		}
+	}
+
+	if this.Strict {
+		for _, name := range c.sectionKeys {
+			if known[name] == nil {
+				report(name, "", c.section(name).Origin(), "未定义的 section")
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func (this *OptionSchema) validate(opt *Option, report func(section, option string, origin Origin, format string, args ...interface{})) {
+	var section = opt.section.Name()
+	var count = len(opt.rawValues())
+
+	if !this.Multiple && count > 1 {
+		report(section, this.Name, opt.OriginAt(1), "只允许有一个值, 实际有 %d 个", count)
+	}
+	if this.Multiple && this.MinValues > 0 && count < this.MinValues {
+		report(section, this.Name, opt.Origin(), "至少需要 %d 个值, 实际有 %d 个", this.MinValues, count)
+	}
+	if this.Multiple && this.MaxValues > 0 && count > this.MaxValues {
+		report(section, this.Name, opt.OriginAt(this.MaxValues), "最多允许 %d 个值, 实际有 %d 个", this.MaxValues, count)
+	}
+
+	for i := 0; i < count; i++ {
+		var value, err = opt.ResolveAt(i)
+		if err == nil {
+			err = this.check(value)
+		}
+		if err != nil {
+			report(section, this.Name, opt.OriginAt(i), "%v", err)
+		}
+	}
+}
+
+func (this *OptionSchema) check(value string) error {
+	var number float64
+	switch this.Type {
+	case TypeInt:
+		var i, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q 不是有效的 %s", value, this.Type)
+		}
+		number = float64(i)
+	case TypeFloat:
+		var f, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q 不是有效的 %s", value, this.Type)
+		}
+		number = f
+	case TypeDuration:
+		var d, err = time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q 不是有效的 %s", value, this.Type)
+		}
+		number = float64(d)
+	case TypeBool:
+		if _, err := parseBool(value); err != nil {
+			return fmt.Errorf("%q 不是有效的 %s", value, this.Type)
+		}
+	case TypeEnum:
+		var found = false
+		for _, e := range this.Enum {
+			if e == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%q 不是允许的值 %s", value, strings.Join(this.Enum, ", "))
+		}
+	}
+
+	if this.Range != nil && (this.Type == TypeInt || this.Type == TypeFloat || this.Type == TypeDuration) {
+		if number < this.Range.Min || number > this.Range.Max {
+			return fmt.Errorf("%q 超出范围 [%v, %v]", value, this.formatBound(this.Range.Min), this.formatBound(this.Range.Max))
+		}
+	}
+
+	if this.Pattern != nil && !this.Pattern.MatchString(value) {
+		return fmt.Errorf("%q 不匹配 %s", value, this.Pattern)
+	}
+	return nil
+}
+
+func (this *OptionSchema) formatBound(v float64) string {
+	if this.Type == TypeDuration {
+		return time.Duration(v).String()
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,85 @@
+package ini4go
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	var schema = &Schema{
+		Strict: true,
+		Sections: []SectionSchema{
+			{
+				Name:     "server",
+				Required: true,
+				Options: []OptionSchema{
+					{Name: "host", Type: TypeString, Required: true, Pattern: regexp.MustCompile(`^[a-z.]+$`)},
+					{Name: "port", Type: TypeInt, Required: true, Range: &Range{Min: 1, Max: 65535}},
+					{Name: "timeout", Type: TypeDuration, Range: &Range{Min: float64(time.Second), Max: float64(time.Minute)}},
+					{Name: "debug", Type: TypeBool},
+					{Name: "mode", Type: TypeEnum, Enum: []string{"dev", "prod"}},
+					{Name: "upstream", Type: TypeString, Multiple: true, MinValues: 2},
+				},
+			},
+			{
+				Name:    "log",
+				Options: []OptionSchema{{Name: "level", Required: true}},
+			},
+		},
+	}
+
+	var r = New(false)
+	r.LoadString("good.conf", "[server]\nhost = localhost\nport = 80\ntimeout = 5s\nmode = dev\nupstream = a\nupstream = b\n")
+	if err := schema.Validate(r); err != nil {
+		t.Error("配置符合 Schema", err)
+	}
+
+	r = New(false)
+	r.LoadString("bad.conf", `[server]
+host = Local_Host
+port = 70000
+port = 80
+timeout = 5m
+debug = maybe
+mode = test
+upstream = a
+unknown = 1
+
+[extra]
+k = v
+`)
+
+	var err = schema.Validate(r)
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatal("应该返回 *ValidationError", err)
+	}
+
+	var expected = map[string]int{"host": 2, "port": 3, "timeout": 5, "debug": 6, "mode": 7, "upstream": 8, "unknown": 9}
+	var found = make(map[string]bool)
+	for _, v := range vErr.Violations {
+		if v.Section == "server" {
+			if line, ok := expected[v.Option]; ok && v.Origin.Line != line && v.Option != "port" {
+				t.Error("Violation 的位置不正确", v)
+			}
+			found[v.Option] = true
+		}
+		if v.Section == "extra" && v.Origin.Line != 11 {
+			t.Error("未定义的 section 的位置不正确", v)
+		}
+		found[v.Section] = true
+	}
+	for key := range expected {
+		if !found[key] {
+			t.Error("应该报告", key)
+		}
+	}
+	if !found["extra"] {
+		t.Error("应该报告未定义的 section extra")
+	}
+	if len(vErr.Violations) != 9 {
+		t.Errorf("应该有 9 处不符合 Schema:\n%v", err)
+	}
+}
@@ -1,12 +1,21 @@
 package ini4go
 
-import "sync"
-
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Section 的 option 列表和注释由自身的锁保护, 通过 MustSection 等方法取得的 Section 可以在多个 goroutine 中同时读写
 type Section struct {
+	mutex      sync.RWMutex
+	parser     *iniParser
 	name       string
 	optionKeys []string
 	options    sync.Map
 	comments   []string
+	origin     Origin
 }
 
 func NewSection(name string) *Section {
@@ -20,11 +29,36 @@ func (this *Section) Name() string {
 	return this.name
 }
 
+// Origin 返回 section 第一次出现的位置
+func (this *Section) Origin() Origin {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	return this.origin
+}
+
+// initOrigin 记录 section 第一次出现的位置
+func (this *Section) initOrigin(origin Origin) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.origin.Order == 0 {
+		this.origin = origin
+	}
+}
+
 func (this *Section) Comments() []string {
-	return this.comments
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	var comments = make([]string, len(this.comments))
+	copy(comments, this.comments)
+	return comments
 }
 
 func (this *Section) Comment() string {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
 	if len(this.comments) > 0 {
 		return this.comments[0]
 	}
@@ -32,33 +66,36 @@ func (this *Section) Comment() string {
 }
 
 func (this *Section) AddComment(comment string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
 	this.comments = append(this.comments, comment)
 }
 
 func (this *Section) newOption(key, iv string) *Option {
+	this.lock()
+	defer this.unlock()
+
 	opt, _ := this.options.Load(key)
 	if opt == nil {
 		opt = NewOption(this, key, iv, nil)
 		this.options.Store(key, opt)
 		this.optionKeys = append(this.optionKeys, key)
+		this.modified()
 	}
 	return opt.(*Option)
 }
 
 func (this *Section) NewOption(key, iv string, value, comments []string) *Option {
-	opt, _ := this.options.Load(key)
-	if opt == nil {
-		opt = NewOption(this, key, iv, nil)
-		this.options.Store(key, opt)
-		this.optionKeys = append(this.optionKeys, key)
-	}
-
-	opt.(*Option).AddValue(value...)
-	opt.(*Option).AddComment(comments...)
-	return opt.(*Option)
+	var opt = this.newOption(key, iv)
+	opt.AddValue(value...)
+	opt.AddComment(comments...)
+	return opt
 }
 
 func (this *Section) RemoveOption(key string) {
+	this.lock()
+	defer this.unlock()
+
 	this.options.Delete(key)
 	var index = -1
 	for i, opt := range this.optionKeys {
@@ -71,6 +108,37 @@ func (this *Section) RemoveOption(key string) {
 	if index >= 0 {
 		this.optionKeys = append(this.optionKeys[0:index], this.optionKeys[index+1:]...)
 	}
+	this.modified()
+}
+
+// lock 在修改 option 列表之前调用, 除了自身的锁之外还会持有配置的 writes 读锁, 见 Snapshot
+func (this *Section) lock() {
+	this.lockWrites()
+	this.mutex.Lock()
+}
+
+func (this *Section) unlock() {
+	this.mutex.Unlock()
+	this.unlockWrites()
+}
+
+// lockWrites 获取配置的 writes 读锁, 通过 Section、Option 的修改不持有配置的锁, Snapshot 通过 writes 暂停这些修改
+func (this *Section) lockWrites() {
+	if this != nil && this.parser != nil && this.parser.block {
+		this.parser.writes.RLock()
+	}
+}
+
+func (this *Section) unlockWrites() {
+	if this != nil && this.parser != nil && this.parser.block {
+		this.parser.writes.RUnlock()
+	}
+}
+
+func (this *Section) modified() {
+	if this.parser != nil {
+		this.parser.modified()
+	}
 }
 
 func (this *Section) HasOption(key string) bool {
@@ -83,17 +151,44 @@ func (this *Section) MustOption(key string) *Option {
 	return opt
 }
 
+// Option 返回名称为 key 的 option, 不存在时返回 nil
 func (this *Section) Option(key string) *Option {
 	opt, _ := this.options.Load(key)
+	if opt == nil {
+		return nil
+	}
 	return opt.(*Option)
 }
 
+// LookupOption 返回名称为 key 的 option, 不存在时返回 nil 和 false
+func (this *Section) LookupOption(key string) (*Option, bool) {
+	var opt = this.Option(key)
+	return opt, opt != nil
+}
+
 func (this *Section) OptionKeys() []string {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
 	var keys = make([]string, len(this.optionKeys))
 	copy(keys, this.optionKeys)
 	return keys
 }
 
+// orderedOptions 按照 option 添加的顺序返回所有的 option, 与 OptionKeys 不同, 不会因为同时删除 option 而得到 nil
+func (this *Section) orderedOptions() []*Option {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	var options = make([]*Option, 0, len(this.optionKeys))
+	for _, key := range this.optionKeys {
+		if opt, _ := this.options.Load(key); opt != nil {
+			options = append(options, opt.(*Option))
+		}
+	}
+	return options
+}
+
 func (this *Section) OptionList() []*Option {
 
 	var oList = make([]*Option, 0)
@@ -105,3 +200,44 @@ func (this *Section) OptionList() []*Option {
 	this.options.Range(f)
 	return oList
 }
+
+// WriteTo 将 section 写入 w, 格式与 WriteToFile 写入的文件中的 section 相同, 返回写入的字节数
+func (this *Section) WriteTo(w io.Writer) (int64, error) {
+	var writer *iniWriter
+	var layouts []*sectionLayout
+	if this.parser != nil {
+		this.parser.RLock()
+		defer this.parser.RUnlock()
+		writer = this.parser.newWriter(w)
+		if this.parser.preserveFormat {
+			layouts = this.parser.layoutsOf(this)
+		}
+	} else {
+		writer = newIniWriter(w)
+	}
+
+	if len(layouts) > 0 {
+		this.parser.writeLayoutTo(writer, layouts)
+	} else {
+		this.write(writer)
+	}
+	var err = writer.Flush()
+	if err == nil {
+		err = writer.err
+	}
+	return writer.written(), err
+}
+
+func (this *Section) write(writer *iniWriter) {
+	for _, c := range this.Comments() {
+		if len(strings.TrimSpace(c)) > 0 {
+			writer.writeText(fmt.Sprintf("# %s\n", c))
+		}
+	}
+
+	writer.writeText(fmt.Sprintf("[%s]\n", this.name))
+
+	for _, opt := range this.orderedOptions() {
+		writeOption(writer, opt)
+	}
+}
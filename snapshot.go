@@ -0,0 +1,320 @@
+package ini4go
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot 是配置在某一时刻的只读副本, 其中的值已经完成变量替换, 读取时不需要加锁,
+// 多次读取得到的是同一时刻的配置; 修改配置不会影响已经取得的 Snapshot
+type Snapshot struct {
+	version     uint64
+	sectionKeys []string
+	sections    map[string]*snapshotSection
+}
+
+type snapshotSection struct {
+	optionKeys []string
+	options    map[string]*snapshotOption
+}
+
+type snapshotOption struct {
+	// 第一个原始的值以及变量替换失败的原因, 用于生成 ValueError
+	raw    string
+	err    error
+	values []string
+}
+
+const (
+	// 生成 Snapshot 时被修改打断之后重试的次数
+	kSnapshotRetries = 3
+)
+
+// Snapshot 返回当前配置的 Snapshot, 配置没有修改时返回同一个 Snapshot,
+// 修改之后第一次调用时重新生成; 引用的环境变量为生成 Snapshot 时的值
+func (this *iniParser) Snapshot() *Snapshot {
+	for i := 0; i < kSnapshotRetries; i++ {
+		var version = atomic.LoadUint64(&this.version)
+		if s, _ := this.snapshot.Load().(*Snapshot); s != nil && s.version == version {
+			return s
+		}
+
+		this.RLock()
+		var s = newSnapshot(this, version)
+		this.RUnlock()
+
+		// 通过 Section、Option 的修改不受配置的锁保护, 生成期间版本有变化时重新生成, 保证 Snapshot 中的值是一致的
+		if atomic.LoadUint64(&this.version) == version {
+			this.snapshot.Store(s)
+			return s
+		}
+	}
+
+	// 多次重试仍然被打断时, 暂停通过 Section、Option 的修改之后再生成
+	this.RLock()
+	defer this.RUnlock()
+	if this.block {
+		this.writes.Lock()
+		defer this.writes.Unlock()
+	}
+
+	var version = atomic.LoadUint64(&this.version)
+	var s = newSnapshot(this, version)
+	this.snapshot.Store(s)
+	return s
+}
+
+func newSnapshot(parser *iniParser, version uint64) *Snapshot {
+	var s = &Snapshot{}
+	s.version = version
+	s.sections = make(map[string]*snapshotSection)
+
+	for _, name := range parser.sectionKeys {
+		var section = parser.section(name)
+		if section == nil {
+			continue
+		}
+
+		var ss = &snapshotSection{}
+		ss.options = make(map[string]*snapshotOption)
+		for _, opt := range section.orderedOptions() {
+			var so = &snapshotOption{}
+			so.values = opt.rawValues()
+			for i, raw := range so.values {
+				var value, err = opt.parseValue(raw, []string{opt.id()})
+				if i == 0 {
+					so.raw = raw
+					so.err = err
+				}
+				if err == nil {
+					so.values[i] = value
+				}
+			}
+			ss.optionKeys = append(ss.optionKeys, opt.key)
+			ss.options[opt.key] = so
+		}
+		s.sectionKeys = append(s.sectionKeys, name)
+		s.sections[name] = ss
+	}
+	return s
+}
+
+func (this *Snapshot) SectionNames() []string {
+	var names = make([]string, len(this.sectionKeys))
+	copy(names, this.sectionKeys)
+	return names
+}
+
+func (this *Snapshot) HasSection(section string) bool {
+	return this.sections[section] != nil
+}
+
+func (this *Snapshot) Options(section string) []string {
+	var s = this.sections[section]
+	if s == nil {
+		return nil
+	}
+	var keys = make([]string, len(s.optionKeys))
+	copy(keys, s.optionKeys)
+	return keys
+}
+
+func (this *Snapshot) HasOption(section, option string) bool {
+	var _, err = this.option(section, option)
+	return err == nil
+}
+
+// option 查找 option, 不存在时返回的错误与 Ini 的 Value 等方法相同
+func (this *Snapshot) option(section, option string) (*snapshotOption, error) {
+	var s = this.sections[section]
+	if s == nil {
+		return nil, fmt.Errorf("ini4go: [%s]: %w", section, ErrSectionNotFound)
+	}
+	var opt = s.options[option]
+	if opt == nil {
+		return nil, fmt.Errorf("ini4go: [%s] %s: %w", section, option, ErrOptionNotFound)
+	}
+	return opt, nil
+}
+
+// value 返回第一个值, 变量替换失败时返回 *ValueError
+func (this *Snapshot) value(section, option string) (*snapshotOption, string, error) {
+	var opt, err = this.option(section, option)
+	if err != nil {
+		return nil, "", err
+	}
+	if opt.err != nil {
+		return nil, "", &ValueError{Section: section, Option: option, Value: opt.raw, Err: opt.err}
+	}
+	if len(opt.values) == 0 {
+		return opt, "", nil
+	}
+	return opt, opt.values[0], nil
+}
+
+func (this *Snapshot) Lookup(section, option string) (string, bool) {
+	var opt, err = this.option(section, option)
+	if err != nil {
+		return "", false
+	}
+	if len(opt.values) == 0 {
+		return "", true
+	}
+	return opt.values[0], true
+}
+
+func (this *Snapshot) GetValue(section, option string) string {
+	var v, _ = this.Lookup(section, option)
+	return v
+}
+
+func (this *Snapshot) GetValues(section, option string) []string {
+	var opt, err = this.option(section, option)
+	if err != nil {
+		return nil
+	}
+	var values = make([]string, len(opt.values))
+	copy(values, opt.values)
+	return values
+}
+
+// Value 返回替换变量之后的值, option 不存在时返回错误
+func (this *Snapshot) Value(section, option string) (string, error) {
+	var _, v, err = this.value(section, option)
+	return v, err
+}
+
+func (this *Snapshot) Int(section, option string) (int, error) {
+	var opt, v, err = this.value(section, option)
+	if err != nil {
+		return 0, err
+	}
+
+	var i int
+	if i, err = strconv.Atoi(v); err != nil {
+		return 0, opt.valueError(section, option, err)
+	}
+	return i, nil
+}
+
+func (this *Snapshot) Int64(section, option string) (int64, error) {
+	var opt, v, err = this.value(section, option)
+	if err != nil {
+		return 0, err
+	}
+
+	var i int64
+	if i, err = strconv.ParseInt(v, 10, 64); err != nil {
+		return 0, opt.valueError(section, option, err)
+	}
+	return i, nil
+}
+
+func (this *Snapshot) Float32(section, option string) (float32, error) {
+	var opt, v, err = this.value(section, option)
+	if err != nil {
+		return 0, err
+	}
+
+	var f float64
+	if f, err = strconv.ParseFloat(v, 32); err != nil {
+		return 0, opt.valueError(section, option, err)
+	}
+	return float32(f), nil
+}
+
+func (this *Snapshot) Float64(section, option string) (float64, error) {
+	var opt, v, err = this.value(section, option)
+	if err != nil {
+		return 0, err
+	}
+
+	var f float64
+	if f, err = strconv.ParseFloat(v, 64); err != nil {
+		return 0, opt.valueError(section, option, err)
+	}
+	return f, nil
+}
+
+func (this *Snapshot) Bool(section, option string) (bool, error) {
+	var opt, v, err = this.value(section, option)
+	if err != nil {
+		return false, err
+	}
+
+	var b bool
+	if b, err = parseBool(v); err != nil {
+		return false, opt.valueError(section, option, err)
+	}
+	return b, nil
+}
+
+func (this *Snapshot) Time(section, option string) (time.Time, error) {
+	return this.TimeWithLayout(section, option, kTimeLayout)
+}
+
+func (this *Snapshot) TimeWithLayout(section, option, layout string) (time.Time, error) {
+	var opt, v, err = this.value(section, option)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var t time.Time
+	if t, err = time.Parse(layout, v); err != nil {
+		return time.Time{}, opt.valueError(section, option, err)
+	}
+	return t, nil
+}
+
+func (this *snapshotOption) valueError(section, option string, err error) *ValueError {
+	return &ValueError{Section: section, Option: option, Value: this.raw, Err: err}
+}
+
+func (this *Snapshot) MustValue(section, option, defaultValue string) string {
+	if v, _ := this.Lookup(section, option); v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+func (this *Snapshot) MustInt(section, option string, defaultValue int) int {
+	var v, err = this.Int(section, option)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+
+func (this *Snapshot) MustInt64(section, option string, defaultValue int64) int64 {
+	var v, err = this.Int64(section, option)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+
+func (this *Snapshot) MustFloat32(section, option string, defaultValue float32) float32 {
+	var v, err = this.Float32(section, option)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+
+func (this *Snapshot) MustFloat64(section, option string, defaultValue float64) float64 {
+	var v, err = this.Float64(section, option)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+
+func (this *Snapshot) MustBool(section, option string, defaultValue bool) bool {
+	var v, err = this.Bool(section, option)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
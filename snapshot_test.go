@@ -0,0 +1,122 @@
+package ini4go
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	var src = `[default]
+home = /opt/app
+
+[s1]
+data = %(home)s/data
+port = 8080
+debug = yes
+bad = %(missing)s
+list = a
+list = b
+`
+	var r = New(true)
+	r.LoadString("app.conf", src)
+
+	var s = r.Snapshot()
+	if s != r.Snapshot() {
+		t.Error("配置没有修改时应该返回同一个 Snapshot")
+	}
+	if s.GetValue("s1", "data") != "/opt/app/data" {
+		t.Error("Snapshot 中的值应该完成变量替换", s.GetValue("s1", "data"))
+	}
+	if s.MustInt("s1", "port", 0) != 8080 || !s.MustBool("s1", "debug", false) {
+		t.Error("s1 -> port, debug 读取错误")
+	}
+	if fmt.Sprint(s.GetValues("s1", "list")) != "[a b]" {
+		t.Error("s1 -> list 应该为 [a b]", s.GetValues("s1", "list"))
+	}
+	if _, err := s.Int("s1", "missing"); !errors.Is(err, ErrOptionNotFound) {
+		t.Error("应该返回 ErrOptionNotFound", err)
+	}
+	if _, err := s.Int("s1", "data"); !errors.Is(err, ErrInvalidValue) {
+		t.Error("应该返回 ErrInvalidValue", err)
+	}
+	var iErr *InterpolationError
+	if _, err := s.Value("s1", "bad"); !errors.As(err, &iErr) {
+		t.Error("应该返回 InterpolationError", err)
+	}
+
+	r.SetValue("s1", "port", "9090")
+	if s.MustInt("s1", "port", 0) != 8080 {
+		t.Error("修改配置不应该影响已经取得的 Snapshot")
+	}
+	var s2 = r.Snapshot()
+	if s2 == s || s2.MustInt("s1", "port", 0) != 9090 {
+		t.Error("修改之后应该生成新的 Snapshot", s2.GetValue("s1", "port"))
+	}
+
+	// 通过 Option 修改也会使 Snapshot 过期
+	r.MustOption("default", "home").SetValue("/srv")
+	if r.Snapshot().GetValue("s1", "data") != "/srv/data" {
+		t.Error("s1 -> data 应该为 /srv/data", r.Snapshot().GetValue("s1", "data"))
+	}
+
+	r.MustSection("s1").RemoveOption("port")
+	if r.Snapshot().HasOption("s1", "port") {
+		t.Error("s1 -> port 已经删除")
+	}
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	var r = New(true)
+	r.LoadString("app.conf", "[s1]\na = 0\nb = %(a)s\n")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 200; i++ {
+			r.MustOption("s1", "a").SetInt(i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			// 同一个 Snapshot 中的值是一致的
+			var s = r.Snapshot()
+			if s.GetValue("s1", "a") != s.GetValue("s1", "b") {
+				t.Error("Snapshot 中的值不一致", s.GetValue("s1", "a"), s.GetValue("s1", "b"))
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	if r.Snapshot().MustInt("s1", "b", 0) != 200 {
+		t.Error("s1 -> b 应该为 200", r.Snapshot().GetValue("s1", "b"))
+	}
+	// 持续的修改不会使 Snapshot 一直重试
+	var done = make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var opt = r.MustOption("s1", "a")
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				opt.SetInt(i)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		var s = r.Snapshot()
+		if s.GetValue("s1", "a") != s.GetValue("s1", "b") {
+			t.Error("Snapshot 中的值不一致", s.GetValue("s1", "a"), s.GetValue("s1", "b"))
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+}
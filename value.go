@@ -0,0 +1,101 @@
+package ini4go
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+func isQuoted(value string) bool {
+	return strings.HasPrefix(value, `"`) || strings.HasPrefix(value, `'`)
+}
+
+// unquoteValue 解析以引号开头的值, 返回去掉引号并处理转义字符之后的值, 以及引号部分在 src 中的长度
+func unquoteValue(src string) (value string, n int, ok bool) {
+	var quote = src[0]
+	var buf strings.Builder
+	for i := 1; i < len(src); i++ {
+		var c = src[i]
+		switch c {
+		case quote:
+			return buf.String(), i + 1, true
+		case '\\':
+			if i+1 >= len(src) {
+				return "", 0, false
+			}
+			i++
+			switch src[i] {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'r':
+				buf.WriteByte('\r')
+			case '"', '\'', '\\':
+				buf.WriteByte(src[i])
+			case 'u':
+				if i+4 >= len(src) {
+					return "", 0, false
+				}
+				var r, err = strconv.ParseUint(src[i+1:i+5], 16, 32)
+				if err != nil {
+					return "", 0, false
+				}
+				buf.WriteRune(rune(r))
+				i += 4
+			default:
+				return "", 0, false
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
+
+// needsQuote 判断值是否需要加上引号才能原样读取, markers 为行内注释的符号
+func needsQuote(value string, markers []string) bool {
+	if value == "" {
+		return false
+	}
+	if value != strings.TrimSpace(value) || isQuoted(value) {
+		return true
+	}
+	if strings.ContainsAny(value, "#;\n\r\t") || strings.HasSuffix(value, "\\") {
+		return true
+	}
+	for _, marker := range markers {
+		if strings.Contains(value, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteValue(value string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		default:
+			if r < ' ' || r == utf8.RuneError {
+				buf.WriteString(`\u`)
+				var hex = strconv.FormatInt(int64(r), 16)
+				buf.WriteString(strings.Repeat("0", 4-len(hex)) + hex)
+				continue
+			}
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
@@ -0,0 +1,311 @@
+package ini4go
+
+import (
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Changes 描述重新加载前后配置的变化
+type Changes struct {
+	// 有变化的 section, 包括新增和删除的 section
+	Sections []string
+
+	// 每个 section 中有变化的 option, 包括新增和删除的 option
+	Options map[string][]string
+}
+
+func (this *Changes) Empty() bool {
+	return len(this.Sections) == 0
+}
+
+// Changed 判断 section 中的 option 是否有变化, option 为空时判断整个 section
+func (this *Changes) Changed(section, option string) bool {
+	if option == "" {
+		for _, name := range this.Sections {
+			if name == section {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, name := range this.Options[section] {
+		if name == option {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *Changes) add(section string, options ...string) {
+	if _, ok := this.Options[section]; !ok {
+		this.Sections = append(this.Sections, section)
+	}
+	this.Options[section] = append(this.Options[section], options...)
+}
+
+// changesBetween 比较 old 和 new 中 option 的原始值
+func changesBetween(old, new *iniParser) *Changes {
+	var changes = &Changes{}
+	changes.Options = make(map[string][]string)
+
+	for _, name := range old.sectionKeys {
+		var s = old.section(name)
+		var v, _ = new.sections.Load(name)
+		if v == nil {
+			changes.add(name, s.OptionKeys()...)
+			continue
+		}
+
+		var ns = v.(*Section)
+		var options []string
+		for _, opt := range s.orderedOptions() {
+			var nOpt, _ = ns.options.Load(opt.key)
+			if nOpt == nil || !reflect.DeepEqual(opt.rawValues(), nOpt.(*Option).rawValues()) {
+				options = append(options, opt.key)
+			}
+		}
+		for _, key := range ns.OptionKeys() {
+			if !s.HasOption(key) {
+				options = append(options, key)
+			}
+		}
+		if len(options) > 0 {
+			changes.add(name, options...)
+		}
+	}
+
+	for _, name := range new.sectionKeys {
+		if _, ok := old.sections.Load(name); !ok {
+			changes.add(name, new.section(name).OptionKeys()...)
+		}
+	}
+	return changes
+}
+
+func (this *iniParser) addSource(sources ...string) {
+	for _, source := range sources {
+		var exists = false
+		for _, s := range this.sources {
+			if s == source {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			this.sources = append(this.sources, source)
+		}
+	}
+}
+
+// Sources 返回通过 Load 和 LoadFiles 加载过的路径
+func (this *iniParser) Sources() []string {
+	this.RLock()
+	defer this.RUnlock()
+
+	var sources = make([]string, len(this.sources))
+	copy(sources, this.sources)
+	return sources
+}
+
+// OnChange 注册重新加载之后的回调函数, 只有配置发生变化时才会调用
+func (this *iniParser) OnChange(f func(changes *Changes)) {
+	this.Lock()
+	defer this.Unlock()
+
+	this.listeners = append(this.listeners, f)
+}
+
+// Reload 重新加载通过 Load 和 LoadFiles 加载过的路径, 加载成功之后替换当前的配置,
+// 加载失败时当前的配置保持不变, 通过 LoadReader 等方法加载的内容以及直接设置的值会被丢弃;
+// 仍然存在的 section 和 option 原地更新, 之前取得的 Section、Option 仍然有效,
+// 已经删除的 section 和 option 对应的 Section、Option 不再属于当前的配置
+func (this *iniParser) Reload() error {
+	var sources = this.Sources()
+
+	var fresh = &iniParser{}
+	fresh.init()
+	this.copySettings(fresh)
+
+	for _, source := range sources {
+		var pathList, err = listFiles(source)
+		if err != nil {
+			return err
+		}
+		if err = fresh.loadFiles(pathList...); err != nil {
+			return err
+		}
+	}
+
+	this.Lock()
+	var changes = changesBetween(this, fresh)
+
+	// fresh 中的 section 和 option 与更新之后的 section 和 option 的对应关系
+	var sections = make(map[*Section]*Section)
+	var options = make(map[*Option]*Option)
+	for _, name := range fresh.sectionKeys {
+		var ns = fresh.section(name)
+		if s := this.section(name); s != nil {
+			s.update(ns, options)
+			sections[ns] = s
+			continue
+		}
+
+		ns.parser = this
+		this.sections.Store(name, ns)
+		sections[ns] = ns
+		for _, opt := range ns.orderedOptions() {
+			options[opt] = opt
+		}
+	}
+	for _, name := range this.sectionKeys {
+		if fresh.section(name) == nil {
+			this.sections.Delete(name)
+		}
+	}
+	this.sectionKeys = fresh.sectionKeys
+
+	for _, layout := range fresh.layout {
+		if layout.section != nil {
+			layout.section = sections[layout.section]
+		}
+		for _, line := range layout.lines {
+			line.option = options[line.option]
+		}
+	}
+	this.layout = fresh.layout
+	this.eol = fresh.eol
+	this.loadCount = fresh.loadCount
+	this.modified()
+
+	var listeners = make([]func(changes *Changes), len(this.listeners))
+	copy(listeners, this.listeners)
+	this.Unlock()
+
+	if !changes.Empty() {
+		for _, f := range listeners {
+			f(changes)
+		}
+	}
+	return nil
+}
+
+// update 使用 fresh 中的内容更新 section, 已经存在的 option 原地更新, options 记录 fresh 中的 option 与更新之后的 option 的对应关系
+func (this *Section) update(fresh *Section, options map[*Option]*Option) {
+	var keys = make(map[string]bool)
+	for _, opt := range fresh.orderedOptions() {
+		keys[opt.key] = true
+		var v, _ = this.options.Load(opt.key)
+		if v == nil {
+			opt.section = this
+			this.options.Store(opt.key, opt)
+			options[opt] = opt
+			continue
+		}
+
+		var current = v.(*Option)
+		current.mutex.Lock()
+		opt.cloneTo(current)
+		current.iv = opt.iv
+		current.mutex.Unlock()
+		options[opt] = current
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.options.Range(func(key, value interface{}) bool {
+		if !keys[key.(string)] {
+			this.options.Delete(key)
+		}
+		return true
+	})
+	this.optionKeys = append([]string(nil), fresh.optionKeys...)
+	this.comments = append([]string(nil), fresh.comments...)
+	this.origin = fresh.origin
+}
+
+func (this *iniParser) copySettings(dst *iniParser) {
+	dst.uniqueOption = this.uniqueOption
+	dst.preserveFormat = this.preserveFormat
+	dst.multiline = this.multiline
+	dst.quoteValues = this.quoteValues
+	dst.inlineCommentMarkers = this.inlineCommentMarkers
+	dst.interpolation = this.interpolation
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// stamps 返回所有加载路径下配置文件的修改时间和大小
+func (this *iniParser) stamps() map[string]fileStamp {
+	var stamps = make(map[string]fileStamp)
+	for _, source := range this.Sources() {
+		var pathList, err = listFiles(source)
+		if err != nil {
+			continue
+		}
+		for _, file := range pathList {
+			if !isConfigFile(file) {
+				continue
+			}
+			if fileInfo, err := os.Stat(file); err == nil {
+				stamps[file] = fileStamp{modTime: fileInfo.ModTime(), size: fileInfo.Size()}
+			}
+		}
+	}
+	return stamps
+}
+
+type Watcher struct {
+	stop chan struct{}
+	once sync.Once
+	done chan struct{}
+}
+
+func (this *Watcher) Stop() {
+	this.once.Do(func() {
+		close(this.stop)
+	})
+	<-this.done
+}
+
+// Watch 每隔 interval 检查一次加载过的文件, 文件有变化时调用 Reload, 重新加载失败时调用 onError;
+// 配置会在其它 goroutine 中被替换, 所以需要通过 New(true) 创建
+func (this *Ini) Watch(interval time.Duration, onError func(err error)) *Watcher {
+	var w = &Watcher{}
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	var last = this.stamps()
+
+	go func() {
+		defer close(w.done)
+
+		var ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+
+			var current = this.stamps()
+			if reflect.DeepEqual(current, last) {
+				continue
+			}
+			last = current
+
+			if err := this.Reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+	return w
+}
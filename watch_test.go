@@ -0,0 +1,84 @@
+package ini4go
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	var dir = t.TempDir()
+	var file = filepath.Join(dir, "app.conf")
+	os.WriteFile(file, []byte("[s1]\nk1 = v1\nk2 = v2\n\n[s2]\nk1 = v1\n"), 0644)
+
+	var r = New(true)
+	if err := r.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载之后之前取得的 Section、Option 仍然有效
+	var section = r.MustSection("s1")
+	var option = r.MustOption("s1", "k2")
+
+	var changed = make(chan *Changes, 1)
+	r.OnChange(func(changes *Changes) {
+		changed <- changes
+	})
+
+	var w = r.Watch(10*time.Millisecond, func(err error) {
+		t.Error(err)
+	})
+
+	os.WriteFile(file, []byte("[s1]\nk1 = v1\nk2 = new value\nk3 = v3\n\n[s3]\nk1 = v1\n"), 0644)
+
+	var changes *Changes
+	select {
+	case changes = <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("文件修改之后应该重新加载")
+	}
+
+	if r.GetValue("s1", "k2") != "new value" || r.HasSection("s2") {
+		t.Error("重新加载之后应该使用新的配置")
+	}
+	if option.Value() != "new value" || !section.HasOption("k3") {
+		t.Error("之前取得的 Section、Option 应该读到新的配置")
+	}
+	option.SetValue("3")
+	section.MustOption("k4").SetValue("4")
+	if r.GetValue("s1", "k2") != "3" || r.GetValue("s1", "k4") != "4" {
+		t.Error("通过之前取得的 Section、Option 修改应该生效")
+	}
+	if len(changes.Sections) != 3 || !changes.Changed("s2", "") || !changes.Changed("s3", "k1") {
+		t.Error("有变化的 section 应该为 s1、s2、s3", changes.Sections)
+	}
+	if !changes.Changed("s1", "k2") || !changes.Changed("s1", "k3") || changes.Changed("s1", "k1") {
+		t.Error("s1 中有变化的 option 应该为 k2、k3", changes.Options["s1"])
+	}
+
+	w.Stop()
+
+	// 重新加载之后再加载的内容排在后面
+	r.LoadString("extra.conf", "[s4]\nk1 = v1\n")
+	if r.Option("s4", "k1").Origin().Order <= r.Option("s1", "k1").Origin().Order {
+		t.Error("重新加载之后应该保留加载的次数")
+	}
+
+	os.WriteFile(file, []byte("[s1\n"), 0644)
+	if err := r.Reload(); err == nil {
+		t.Error("文件格式错误时应该返回错误")
+	}
+	if r.GetValue("s1", "k2") != "3" {
+		t.Error("重新加载失败时应该保留原有的配置")
+	}
+
+	// 加载失败的路径不会被记录
+	os.WriteFile(file, []byte("[s1]\nk1 = v1\n"), 0644)
+	if err := r.LoadFiles(filepath.Join(dir, "missing.conf")); err == nil {
+		t.Error("文件不存在时应该返回错误")
+	}
+	if len(r.Sources()) != 1 || r.Reload() != nil {
+		t.Error("加载失败的路径不应该被记录", r.Sources())
+	}
+}